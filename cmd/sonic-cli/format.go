@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+// formatReply renders the parsed reply of a raw command:
+//
+//	OK and PONG as is
+//	RESULT <n> as (integer) n
+//	RESULT key(value)... as one key: value per line
+//	EVENT results as a numbered list
+//	ERR as (error) ...
+func formatReply(cmd *sonic.Cmd) string {
+	if err := cmd.Err(); err != nil {
+		return "(error) " + err.Error()
+	}
+
+	switch val := cmd.Val().(type) {
+	case nil:
+		return "ENDED"
+	case string:
+		return val
+	case int64:
+		// Sonic answers OK to PUSH and TRIGGER.
+		return "OK"
+	case []string:
+		if strings.EqualFold(cmd.Name(), sonic.CmdControlInfo) {
+			return formatInfo(val)
+		}
+		if isEvent(cmd.Name()) {
+			return formatList(val)
+		}
+		if len(val) == 1 {
+			return "(integer) " + val[0]
+		}
+		return strings.Join(val, " ")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func isEvent(name string) bool {
+	switch strings.ToUpper(name) {
+	case sonic.CmdSearchQuery, sonic.CmdSearchSuggest, sonic.CmdSearchList:
+		return true
+	}
+	return false
+}
+
+func formatList(vals []string) string {
+	if len(vals) == 0 {
+		return "(empty list)"
+	}
+
+	var b strings.Builder
+	width := len(fmt.Sprint(len(vals)))
+	for i, val := range vals {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%*d) %s", width, i+1, val)
+	}
+	return b.String()
+}
+
+func formatInfo(fields []string) string {
+	lines := make([]string, len(fields))
+	for i, field := range fields {
+		if open := strings.IndexByte(field, '('); open > 0 && strings.HasSuffix(field, ")") {
+			field = field[:open] + ": " + field[open+1:len(field)-1]
+		}
+		lines[i] = field
+	}
+	return strings.Join(lines, "\n")
+}
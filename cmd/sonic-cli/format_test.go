@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+func TestFormatReply(t *testing.T) {
+	// Numbers are right aligned from ten results on.
+	var ten, tenLines []string
+	for i := 1; i <= 10; i++ {
+		ten = append(ten, fmt.Sprintf("obj:%d", i))
+		tenLines = append(tenLines, fmt.Sprintf("%2d) obj:%d", i, i))
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		val  interface{}
+		err  error
+		want string
+	}{
+		{"pong", []string{"PING"}, "PONG", nil, "PONG"},
+		{"ok", []string{"PUSH", "c", "b", "o", `"hello"`}, int64(0), nil, "OK"},
+		{"ended", []string{"QUIT"}, nil, nil, "ENDED"},
+		{"integer", []string{"COUNT", "c"}, []string{"42"}, nil, "(integer) 42"},
+		{"fields", []string{"START", "search"}, []string{"search", "protocol(1)", "buffer(20000)"}, nil, "search protocol(1) buffer(20000)"},
+		{"event", []string{"QUERY", "c", "b", `"hello"`}, []string{"obj:1", "obj:2"}, nil, "1) obj:1\n2) obj:2"},
+		{"lowercase event", []string{"suggest", "c", "b", `"hel"`}, []string{"hello"}, nil, "1) hello"},
+		{"empty event", []string{"LIST", "c", "b"}, []string{}, nil, "(empty list)"},
+		{"aligned event", []string{"QUERY", "c", "b", `"hello"`}, ten, nil, strings.Join(tenLines, "\n")},
+		{"info", []string{"INFO"}, []string{"uptime(120)", "clients_connected(2)", "odd"}, nil, "uptime: 120\nclients_connected: 2\nodd"},
+		{"error", []string{"QUERY", "c"}, nil, errors.New("ERR invalid_format(QUERY <collection> <bucket> \"<terms>\")"), "(error) ERR invalid_format(QUERY <collection> <bucket> \"<terms>\")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := sonic.NewCmd(context.Background(), tt.args...)
+			cmd.SetVal(tt.val)
+			if tt.err != nil {
+				cmd.SetErr(tt.err)
+			}
+
+			if got := formatReply(cmd); got != tt.want {
+				t.Fatalf("formatReply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Command sonic-cli sends raw commands to a Sonic server and prints the
+// parsed replies, like redis-cli.
+//
+// Usage:
+//
+//	sonic-cli [flags]                 interactive prompt
+//	sonic-cli [flags] < commands.txt  one command per line from stdin
+//	sonic-cli [flags] COMMAND ARGS... a single command
+//
+// At the prompt, CHANNEL <search|ingest|control> switches channel, HISTORY
+// lists the previous commands, !N runs the Nth of them again and EXIT leaves.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+type cli struct {
+	opt     sonic.Options
+	timeout time.Duration
+
+	mode    string
+	clients map[string]*sonic.Client
+
+	history     []string
+	historyFile *os.File
+
+	out io.Writer
+}
+
+func main() {
+	var (
+		addr     = flag.String("addr", "localhost:1491", "Sonic server host:port")
+		password = flag.String("password", "SecretPassword", "channel auth password")
+		mode     = flag.String("channel", sonic.ChannelSearch, "channel mode: search, ingest or control")
+		timeout  = flag.Duration("timeout", 5*time.Second, "timeout of every command")
+		history  = flag.String("history", defaultHistoryPath(), "history file of the prompt, empty to disable")
+	)
+	flag.Parse()
+
+	c := &cli{
+		opt: sonic.Options{
+			Addr:         *addr,
+			AuthPassword: *password,
+			PoolSize:     1,
+		},
+		timeout: *timeout,
+		clients: make(map[string]*sonic.Client),
+		out:     os.Stdout,
+	}
+	defer c.close()
+
+	if err := c.setChannel(*mode); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if flag.NArg() > 0 {
+		if !c.run(commandArgs(flag.Args())) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	interactive := isTerminal(os.Stdin)
+	if interactive && *history != "" {
+		c.loadHistory(*history)
+	}
+
+	if !c.loop(os.Stdin, interactive) {
+		os.Exit(1)
+	}
+}
+
+// loop runs every line of in and reports whether all of them succeeded.
+func (c *cli) loop(in io.Reader, interactive bool) bool {
+	ok := true
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for {
+		if interactive {
+			fmt.Fprintf(c.out, "%s[%s]> ", c.opt.Addr, c.mode)
+		}
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (!interactive && strings.HasPrefix(line, "#")) {
+			continue
+		}
+
+		if interactive && strings.HasPrefix(line, "!") {
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(c.history) {
+				fmt.Fprintln(c.out, "(error) no such history entry")
+				continue
+			}
+			line = c.history[n-1]
+			fmt.Fprintln(c.out, line)
+		}
+		if interactive {
+			c.addHistory(line)
+		}
+
+		args := splitLine(line)
+		switch strings.ToUpper(args[0]) {
+		case "EXIT":
+			return ok
+		case "HISTORY":
+			for i, h := range c.history {
+				fmt.Fprintf(c.out, "%4d  %s\n", i+1, h)
+			}
+			continue
+		case "CHANNEL":
+			if len(args) != 2 {
+				fmt.Fprintln(c.out, "(error) usage: CHANNEL <search|ingest|control>")
+				ok = false
+			} else if err := c.setChannel(args[1]); err != nil {
+				fmt.Fprintf(c.out, "(error) %v\n", err)
+				ok = false
+			}
+			continue
+		}
+
+		if !c.run(args) {
+			ok = false
+		}
+		if strings.EqualFold(args[0], sonic.CmdQuit) {
+			return ok
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "sonic-cli: %v\n", err)
+		return false
+	}
+	return ok
+}
+
+// run sends a command on the current channel and prints its reply.
+func (c *cli) run(args []string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	cmd := c.clients[c.mode].Do(ctx, args...)
+	fmt.Fprintln(c.out, formatReply(cmd))
+	return cmd.Err() == nil
+}
+
+func (c *cli) setChannel(mode string) error {
+	mode = strings.ToLower(mode)
+	switch mode {
+	case sonic.ChannelSearch, sonic.ChannelIngest, sonic.ChannelControl:
+	default:
+		return fmt.Errorf("unknown channel %q", mode)
+	}
+
+	if _, ok := c.clients[mode]; !ok {
+		opt := c.opt
+		opt.ChannelMode = mode
+		c.clients[mode] = sonic.NewClient(&opt)
+	}
+	c.mode = mode
+	return nil
+}
+
+func (c *cli) close() {
+	for _, client := range c.clients {
+		_ = client.Close()
+	}
+	if c.historyFile != nil {
+		_ = c.historyFile.Close()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sonic_cli_history")
+}
+
+func (c *cli) loadHistory(path string) {
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				c.history = append(c.history, line)
+			}
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sonic-cli: history disabled: %v\n", err)
+		return
+	}
+	c.historyFile = f
+}
+
+func (c *cli) addHistory(line string) {
+	c.history = append(c.history, line)
+	if c.historyFile != nil {
+		_, _ = c.historyFile.WriteString(line + "\n")
+	}
+}
+
+// commandArgs returns the command given as arguments, or as one quoted
+// argument.
+func commandArgs(args []string) []string {
+	return splitLine(strings.Join(args, " "))
+}
+
+// splitLine splits a command line on spaces, keeping quoted text with its
+// quotes as one argument since Sonic expects it quoted.
+func splitLine(line string) []string {
+	var args []string
+	var cur strings.Builder
+	var quoted, escaped bool
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if cur.Len() > 0 {
+				args = append(args, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
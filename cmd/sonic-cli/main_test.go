@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  \t ", nil},
+		{"PING", []string{"PING"}},
+		{"  COUNT   messages\tdefault ", []string{"COUNT", "messages", "default"}},
+		{`QUERY messages default "hello world"`, []string{"QUERY", "messages", "default", `"hello world"`}},
+		{`QUERY messages default "hello world" LIMIT(10)`, []string{"QUERY", "messages", "default", `"hello world"`, "LIMIT(10)"}},
+		{`PUSH c b o "say \"hi\" \\ there"`, []string{"PUSH", "c", "b", "o", `"say \"hi\" \\ there"`}},
+		{`PUSH c b o "tab	and  spaces"`, []string{"PUSH", "c", "b", "o", `"tab	and  spaces"`}},
+		{`PUSH c b o ""`, []string{"PUSH", "c", "b", "o", `""`}},
+		// An unterminated quote runs to the end of the line.
+		{`QUERY c b "hello wor`, []string{"QUERY", "c", "b", `"hello wor`}},
+		// Backslashes only escape inside quotes.
+		{`TRIGGER backup a\b`, []string{"TRIGGER", "backup", `a\b`}},
+	}
+
+	for _, tt := range tests {
+		if got := splitLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	want := []string{"QUERY", "messages", "default", `"hello world"`, "LIMIT(10)"}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"one argument each", []string{"QUERY", "messages", "default", `"hello world"`, "LIMIT(10)"}},
+		{"whole line as one argument", []string{`QUERY messages default "hello world" LIMIT(10)`}},
+		{"command then quoted rest", []string{"QUERY", `messages default "hello world" LIMIT(10)`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandArgs(tt.args); !reflect.DeepEqual(got, want) {
+				t.Fatalf("commandArgs(%q) = %q, want %q", tt.args, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+// Command sonic-import ingests JSON Lines or CSV records into Sonic.
+//
+// Usage:
+//
+//	sonic-import [flags] <file.jsonl|file.csv|->
+//
+// Every record is pushed as one object. The -*-field flags name the record
+// fields holding the collection, bucket, object, text and lang; nested JSON
+// fields are named with dots, e.g. meta.lang. -collection, -bucket and -lang
+// are used when a record has no such value. CSV files need a header line.
+// Langs are ISO 639 codes, BCP-47 tags like pt-BR or English names.
+//
+// Text too long for the server buffer is split into several PUSH commands.
+// Progress is printed to stderr with the byte offset to pass to -offset to
+// resume an interrupted import. The offset never moves past a failed record,
+// so resuming retries every failed record. The first interrupt stops reading
+// and waits for the records in flight, a second one exits at once.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+type mapping struct {
+	collectionField string
+	bucketField     string
+	objectField     string
+	textFields      []string
+	langField       string
+
+	collection string
+	bucket     string
+	lang       sonic.Lang
+}
+
+// item maps a record to the item to push.
+func (m *mapping) item(rec record) (sonic.IngestItem, error) {
+	item := sonic.IngestItem{
+		Collection: m.collection,
+		Bucket:     m.bucket,
+		Object:     rec(m.objectField),
+		Lang:       m.lang,
+	}
+	if m.collectionField != "" {
+		if v := rec(m.collectionField); v != "" {
+			item.Collection = v
+		}
+	}
+	if m.bucketField != "" {
+		if v := rec(m.bucketField); v != "" {
+			item.Bucket = v
+		}
+	}
+	if m.langField != "" {
+		if v := rec(m.langField); v != "" {
+			lang, err := sonic.ParseLang(v)
+			if err != nil {
+				return item, err
+			}
+			item.Lang = lang
+		}
+	}
+
+	var text []string
+	for _, field := range m.textFields {
+		if v := strings.TrimSpace(rec(field)); v != "" {
+			text = append(text, v)
+		}
+	}
+	item.Item = strings.Join(text, " ")
+
+	switch {
+	case item.Collection == "":
+		return item, errors.New("no collection")
+	case item.Bucket == "":
+		return item, errors.New("no bucket")
+	case item.Object == "":
+		return item, fmt.Errorf("no object in field %q", m.objectField)
+	case item.Item == "":
+		return item, errors.New("no text")
+	}
+	return item, nil
+}
+
+type stats struct {
+	records  int64 // atomic
+	imported int64 // atomic
+	failed   int64 // atomic
+	chunks   int64 // atomic
+	offset   int64 // atomic, start of the first failed record or end of the last record handled
+}
+
+func (s *stats) String() string {
+	return fmt.Sprintf("%d records, %d imported, %d failed, %d commands, offset %d",
+		atomic.LoadInt64(&s.records), atomic.LoadInt64(&s.imported),
+		atomic.LoadInt64(&s.failed), atomic.LoadInt64(&s.chunks),
+		atomic.LoadInt64(&s.offset))
+}
+
+func main() {
+	os.Exit(importMain())
+}
+
+// importMain runs the import and returns the exit code, once every
+// deferred cleanup has run.
+func importMain() int {
+	var (
+		addr        = flag.String("addr", "localhost:1491", "Sonic server host:port")
+		password    = flag.String("password", "SecretPassword", "channel auth password")
+		format      = flag.String("format", "", "input format: jsonl or csv, guessed from the file extension if empty")
+		comma       = flag.String("comma", ",", "CSV field delimiter")
+		collection  = flag.String("collection", "", "collection of records without -collection-field value")
+		bucket      = flag.String("bucket", "", "bucket of records without -bucket-field value")
+		lang        = flag.String("lang", "", "lang of records without -lang-field value, as an ISO 639 code, BCP-47 tag or English name; empty to let Sonic detect it")
+		collField   = flag.String("collection-field", "", "field holding the collection")
+		bucketField = flag.String("bucket-field", "", "field holding the bucket")
+		objectField = flag.String("object-field", "id", "field holding the object")
+		textField   = flag.String("text-field", "text", "comma separated fields holding the text, joined with spaces")
+		langField   = flag.String("lang-field", "", "field holding the lang")
+		concurrency = flag.Int("concurrency", 4, "number of ingest connections pushing at the same time")
+		batchSize   = flag.Int("batch", 100, "number of records pushed between two progress offsets")
+		offset      = flag.Int64("offset", 0, "byte offset of the input to resume from")
+		progress    = flag.Duration("progress", 2*time.Second, "interval between progress lines, 0 to disable")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sonic-import [flags] <file.jsonl|file.csv|->\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *concurrency < 1 || *batchSize < 1 {
+		flag.Usage()
+		return 2
+	}
+
+	defaultLang, err := sonic.ParseLang(*lang)
+	if err != nil {
+		return fatal(err)
+	}
+
+	m := &mapping{
+		collectionField: *collField,
+		bucketField:     *bucketField,
+		objectField:     *objectField,
+		textFields:      strings.Split(*textField, ","),
+		langField:       *langField,
+		collection:      *collection,
+		bucket:          *bucket,
+		lang:            defaultLang,
+	}
+
+	rd, closeInput, err := openInput(flag.Arg(0), *format, *comma, *offset)
+	if err != nil {
+		return fatal(err)
+	}
+	defer closeInput()
+
+	client := sonic.NewClient(&sonic.Options{
+		Addr:             *addr,
+		AuthPassword:     *password,
+		ChannelMode:      sonic.ChannelIngest,
+		PoolSize:         *concurrency,
+		BatchConcurrency: *concurrency,
+	})
+	defer client.Close()
+
+	st := &stats{offset: *offset}
+	start := time.Now()
+
+	if *progress > 0 {
+		ticker := time.NewTicker(*progress)
+		defer ticker.Stop()
+		go func() {
+			for range ticker.C {
+				rate := float64(atomic.LoadInt64(&st.records)) / time.Since(start).Seconds()
+				fmt.Fprintf(os.Stderr, "sonic-import: %s (%.0f records/s)\n", st, rate)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore the default handlers once interrupted, so a second
+		// interrupt doesn't wait for the records in flight.
+		<-ctx.Done()
+		stop()
+	}()
+	err = run(ctx, client, rd, m, *batchSize, st)
+	stop()
+
+	fmt.Fprintf(os.Stderr, "sonic-import: done in %s: %s\n", time.Since(start).Round(time.Millisecond), st)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sonic-import: stopped: %v\n", err)
+		fmt.Fprintf(os.Stderr, "sonic-import: resume with -offset %d\n", atomic.LoadInt64(&st.offset))
+		return 1
+	}
+	if atomic.LoadInt64(&st.failed) > 0 {
+		fmt.Fprintf(os.Stderr, "sonic-import: retry failed records with -offset %d\n", atomic.LoadInt64(&st.offset))
+		return 1
+	}
+	return 0
+}
+
+// run reads records until the end of the input or ctx is done, and pushes
+// them in batches. Offsets are only advanced once a batch is pushed, and
+// stop at the first failed record.
+func run(ctx context.Context, client *sonic.Client, rd recordReader, m *mapping, batchSize int, st *stats) error {
+	batch := make([]sonic.IngestItem, 0, batchSize)
+	starts := make([]int64, 0, batchSize) // offset of every batch record
+	end := atomic.LoadInt64(&st.offset)
+	failedAt := int64(-1) // offset of the first failed record
+
+	fail := func(start int64) {
+		atomic.AddInt64(&st.failed, 1)
+		if failedAt < 0 {
+			failedAt = start
+		}
+	}
+
+	flush := func() {
+		if len(batch) > 0 {
+			// Records in flight are pushed even if ctx is done.
+			results := client.MPush(context.Background(), batch).Val()
+			for i, res := range results {
+				if res.Err != nil {
+					fail(starts[i])
+					fmt.Fprintf(os.Stderr, "sonic-import: %s/%s/%s: %v\n",
+						res.Item.Collection, res.Item.Bucket, res.Item.Object, res.Err)
+					continue
+				}
+				atomic.AddInt64(&st.imported, 1)
+				atomic.AddInt64(&st.chunks, int64(res.Chunks))
+			}
+			batch = batch[:0]
+			starts = starts[:0]
+		}
+		if failedAt >= 0 {
+			atomic.StoreInt64(&st.offset, failedAt)
+		} else {
+			atomic.StoreInt64(&st.offset, end)
+		}
+	}
+
+	for ctx.Err() == nil {
+		start := end
+		rec, recEnd, err := rd.Next()
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+
+		var perr *parseError
+		if errors.As(err, &perr) {
+			atomic.AddInt64(&st.records, 1)
+			fail(start)
+			fmt.Fprintf(os.Stderr, "sonic-import: %v\n", err)
+			end = recEnd
+			continue
+		}
+		if err != nil {
+			flush()
+			return err
+		}
+		atomic.AddInt64(&st.records, 1)
+		end = recEnd
+
+		item, err := m.item(rec)
+		if err != nil {
+			fail(start)
+			fmt.Fprintf(os.Stderr, "sonic-import: record before offset %d: %v\n", recEnd, err)
+			continue
+		}
+
+		batch = append(batch, item)
+		starts = append(starts, start)
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+
+	flush()
+	return ctx.Err()
+}
+
+// openInput opens the file at path, or stdin for "-", and returns a reader
+// of its records starting at offset.
+func openInput(path, format, comma string, offset int64) (recordReader, func(), error) {
+	if format == "" {
+		format = "jsonl"
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			format = "csv"
+		}
+	}
+
+	var f *os.File
+	if path == "-" {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	closeInput := func() { _ = f.Close() }
+
+	var rd recordReader
+	var err error
+	switch format {
+	case "jsonl":
+		rd, err = newJSONLReader(f, offset)
+	case "csv":
+		delim := []rune(comma)
+		if len(delim) != 1 {
+			err = fmt.Errorf("invalid CSV delimiter %q", comma)
+			break
+		}
+		rd, err = newCSVReader(f, offset, delim[0])
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		closeInput()
+		return nil, nil, err
+	}
+	return rd, closeInput, nil
+}
+
+// fatal prints err and returns the exit code of bad arguments.
+func fatal(err error) int {
+	fmt.Fprintf(os.Stderr, "sonic-import: %v\n", err)
+	return 2
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// record returns the value of a field, empty if missing.
+type record func(field string) string
+
+// recordReader streams records and the byte offset following each of them,
+// so an import can resume right after the last record ingested.
+type recordReader interface {
+	Next() (rec record, end int64, err error)
+}
+
+// parseError reports a malformed record, which is skipped.
+type parseError struct {
+	offset int64 // end of the record
+	err    error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("malformed record before offset %d: %v", e.offset, e.err)
+}
+
+// lineReader reads lines and keeps track of the byte offset.
+type lineReader struct {
+	rd     *bufio.Reader
+	offset int64
+}
+
+func (r *lineReader) readLine() ([]byte, error) {
+	line, err := r.rd.ReadBytes('\n')
+	r.offset += int64(len(line))
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	return line, err
+}
+
+// skip discards the input up to offset, which should be the end of a record.
+func (r *lineReader) skip(offset int64) error {
+	if offset <= r.offset {
+		return nil
+	}
+	n, err := r.rd.Discard(int(offset - r.offset))
+	r.offset += int64(n)
+	if err == io.EOF {
+		return fmt.Errorf("offset %d is past the end of the input (%d bytes)", offset, r.offset)
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type jsonlReader struct {
+	lineReader
+}
+
+func newJSONLReader(rd io.Reader, offset int64) (*jsonlReader, error) {
+	r := &jsonlReader{lineReader{rd: bufio.NewReader(rd)}}
+	if err := r.skip(offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *jsonlReader) Next() (record, int64, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, r.offset, err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var obj map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			return nil, r.offset, &parseError{offset: r.offset, err: err}
+		}
+
+		return func(field string) string {
+			return jsonField(obj, field)
+		}, r.offset, nil
+	}
+}
+
+// jsonField returns the value at a dotted path, e.g. "meta.lang".
+func jsonField(obj map[string]interface{}, path string) string {
+	var val interface{} = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		val = m[key]
+	}
+
+	switch val := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, v := range val {
+			if s, ok := v.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, " ")
+	default:
+		return ""
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type csvReader struct {
+	lineReader
+	comma  rune
+	header map[string]int
+}
+
+// newCSVReader reads the header, then skips to offset unless it is within
+// the header.
+func newCSVReader(rd io.Reader, offset int64, comma rune) (*csvReader, error) {
+	r := &csvReader{
+		lineReader: lineReader{rd: bufio.NewReader(rd)},
+		comma:      comma,
+	}
+
+	names, err := r.readRecord()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("missing CSV header")
+		}
+		return nil, err
+	}
+	r.header = make(map[string]int, len(names))
+	for i, name := range names {
+		r.header[strings.TrimSpace(name)] = i
+	}
+
+	if err := r.skip(offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readRecord reads the next record, which spans lines when a quoted field
+// holds newlines.
+func (r *csvReader) readRecord() ([]string, error) {
+	var buf []byte
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				return nil, fmt.Errorf("unterminated quoted CSV field before offset %d", r.offset)
+			}
+			return nil, err
+		}
+		buf = append(buf, line...)
+
+		// A newline inside a quoted field leaves an odd number of quotes.
+		if bytes.Count(buf, []byte{'"'})%2 == 1 {
+			continue
+		}
+		if len(bytes.TrimSpace(buf)) == 0 {
+			buf = buf[:0]
+			continue
+		}
+
+		cr := csv.NewReader(bytes.NewReader(buf))
+		cr.Comma = r.comma
+		cr.FieldsPerRecord = -1
+		fields, err := cr.Read()
+		if err != nil {
+			return nil, &parseError{offset: r.offset, err: err}
+		}
+		return fields, nil
+	}
+}
+
+func (r *csvReader) Next() (record, int64, error) {
+	fields, err := r.readRecord()
+	if err != nil {
+		return nil, r.offset, err
+	}
+
+	return func(field string) string {
+		if i, ok := r.header[field]; ok && i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}, r.offset, nil
+}
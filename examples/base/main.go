@@ -18,45 +18,36 @@ func main() {
 		ChannelMode:  sonic.ChannelIngest,
 	})
 
-	results, err := sonicSearch.Ping(ctx).Result()
+	pong, err := sonicSearch.Ping(ctx).Result()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Results: %v\n", results)
+	fmt.Printf("Results: %v\n", pong)
 
 	text := "Bir isim gerekiyor acaba ne olsun"
 
-	var chunks []string
-
-	if sonicSearch.IsPushContentReady(text) {
-		chunks = sonicSearch.SplitPushContent(text)
-	} else {
-		chunks = append(chunks, text)
+	// Text too long for the connection buffer is split automatically
+	pushed, err := sonicSearch.Push(ctx, "collection", "bucket", "user:1", text, sonic.LangTur).Result()
+	if err != nil {
+		panic(err)
 	}
 
-	for _, text := range chunks {
-		results, err := sonicSearch.Push(ctx, "collection", "bucket", "user:1", text, sonic.LangTur).Result()
-		if err != nil {
-			panic(err)
-		}
-
-		fmt.Printf("Results: %v\n", results)
-	}
+	fmt.Printf("Results: %v\n", pushed)
 
-	results, err = sonicSearch.FlushCollection(ctx, "collection").Result()
+	flushed, err := sonicSearch.FlushCollection(ctx, "collection").Result()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Results: %v\n", results)
+	fmt.Printf("Results: %v\n", flushed)
 
-	results, err = sonicSearch.Pop(ctx, "collection", "bucket", "user:1", text).Result()
+	popped, err := sonicSearch.Pop(ctx, "collection", "bucket", "user:1", text).Result()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Results: %v\n", results)
+	fmt.Printf("Results: %v\n", popped)
 
 	// SEARCH MODE ----------------------------------------
 	for i := 0; i < 2; i++ {
@@ -68,7 +59,7 @@ func main() {
 			ChannelMode:  sonic.ChannelSearch,
 		})
 
-		results, err = sonicSearch2.Query(ctx, "collection", "bucket", "nededin sen", 10, 0, sonic.LangTur).Slice()
+		results, err := sonicSearch2.Query(ctx, "collection", "bucket", "nededin sen", 10, 0, sonic.LangTur).Result()
 		if err != nil {
 			panic(err)
 		}
@@ -82,7 +73,7 @@ func main() {
 
 		fmt.Printf("Results: %v\n", results)
 
-		results, err = sonicSearch2.Suggest(ctx, "collection", "bucket", "gerek", 10).Slice()
+		results, err = sonicSearch2.Suggest(ctx, "collection", "bucket", "gerek", 10).Result()
 		if err != nil {
 			panic(err)
 		}
@@ -103,19 +94,19 @@ func main() {
 		ChannelMode:  sonic.ChannelControl,
 	})
 
-	results, err = sonicSearch3.Info(ctx).Result()
+	info, err := sonicSearch3.Info(ctx).Result()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Results: %v\n", results)
+	fmt.Printf("Results: %v\n", info)
 
-	results, err = sonicSearch3.Trigger(ctx, sonic.TriggerActionConsolidate, "").Bool()
+	status, err := sonicSearch3.Trigger(ctx, sonic.TriggerActionConsolidate, "").Result()
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Printf("Results: %v\n", results)
+	fmt.Printf("Results: %v\n", status)
 
 	err = sonicSearch3.Quit(ctx).Err()
 	if err != nil {
@@ -0,0 +1,229 @@
+// Package langdetect detects the language of texts from their character
+// n-grams, to fill the LANG of PUSH and QUERY commands instead of relying
+// on Sonic's detection, which often misfires on short texts.
+//
+// Profiles are built from the sample texts embedded in the package, one per
+// supported language. Plug a Detector into a client with
+// sonic.Options.LangDetector:
+//
+//	detector, err := langdetect.New(&langdetect.Options{MinConfidence: 0.9})
+//	if err != nil {
+//		panic(err)
+//	}
+//	client := sonic.NewClient(&sonic.Options{LangDetector: detector})
+package langdetect
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+// Size of the longest n-grams.
+const maxN = 3
+
+//go:embed samples/*.txt
+var samples embed.FS
+
+// Options keeps the settings of a Detector.
+type Options struct {
+	// Languages to choose from, a subset of Langs(); New fails on any
+	// other language.
+	// Default is every language of Langs().
+	Langs []sonic.Lang
+
+	// Minimum confidence, between 0 and 1, of the language returned by
+	// DetectLang. Texts detected with a lower confidence, such as single
+	// words or names, are left to Sonic.
+	// Default is 0.8; -1 uses any detected language.
+	MinConfidence float64
+}
+
+func (opt *Options) init() {
+	switch opt.MinConfidence {
+	case -1:
+		opt.MinConfidence = 0
+	case 0:
+		opt.MinConfidence = 0.8
+	}
+}
+
+// Score is the confidence, between 0 and 1, that a text is written in Lang.
+type Score struct {
+	Lang       sonic.Lang
+	Confidence float64
+}
+
+type profile struct {
+	lang   sonic.Lang
+	counts map[string]int
+	total  int
+}
+
+// Detector detects the language of texts. It's safe for concurrent use by
+// multiple goroutines.
+type Detector struct {
+	opt      *Options
+	profiles []*profile
+	vocab    int // number of distinct n-grams of all profiles
+}
+
+var profiles = loadProfiles()
+
+func loadProfiles() map[sonic.Lang]*profile {
+	entries, err := samples.ReadDir("samples")
+	if err != nil {
+		panic(err)
+	}
+
+	m := make(map[sonic.Lang]*profile, len(entries))
+	for _, entry := range entries {
+		data, err := samples.ReadFile(path.Join("samples", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		p := &profile{
+			lang:   sonic.Lang(strings.TrimSuffix(entry.Name(), ".txt")),
+			counts: make(map[string]int),
+		}
+		for _, gram := range ngrams(string(data)) {
+			p.counts[gram]++
+			p.total++
+		}
+		m[p.lang] = p
+	}
+	return m
+}
+
+// Langs returns the languages a Detector can detect, sorted by code.
+func Langs() []sonic.Lang {
+	langs := make([]sonic.Lang, 0, len(profiles))
+	for lang := range profiles {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool { return langs[i] < langs[j] })
+	return langs
+}
+
+// New returns a Detector, or an error if a language of Options.Langs has
+// no profile.
+func New(opt *Options) (*Detector, error) {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	opt = &o
+	opt.init()
+
+	langs := opt.Langs
+	if len(langs) == 0 {
+		langs = Langs()
+	}
+
+	d := &Detector{opt: opt}
+	vocab := make(map[string]struct{})
+	for _, lang := range langs {
+		p, ok := profiles[lang]
+		if !ok {
+			return nil, fmt.Errorf("langdetect: no profile for lang %q", string(lang))
+		}
+		d.profiles = append(d.profiles, p)
+		for gram := range p.counts {
+			vocab[gram] = struct{}{}
+		}
+	}
+	d.vocab = len(vocab)
+	return d, nil
+}
+
+// Scores returns the confidence of every language for text, highest
+// first, or nil if text has no letters. Confidences add up to 1.
+func (d *Detector) Scores(text string) []Score {
+	grams := ngrams(text)
+	if len(grams) == 0 || len(d.profiles) == 0 {
+		return nil
+	}
+
+	// Naive Bayes with add-one smoothing over the n-grams of the text. The
+	// n-grams of a character are not independent, so log-likelihoods are
+	// divided by their number of sizes to keep confidences realistic.
+	scores := make([]Score, len(d.profiles))
+	logs := make([]float64, len(d.profiles))
+	max := math.Inf(-1)
+	for i, p := range d.profiles {
+		denom := math.Log(float64(p.total + d.vocab + 1))
+		for _, gram := range grams {
+			logs[i] += math.Log(float64(p.counts[gram]+1)) - denom
+		}
+		logs[i] /= maxN
+		if logs[i] > max {
+			max = logs[i]
+		}
+	}
+
+	var sum float64
+	for i, p := range d.profiles {
+		scores[i].Lang = p.lang
+		scores[i].Confidence = math.Exp(logs[i] - max)
+		sum += scores[i].Confidence
+	}
+	for i := range scores {
+		scores[i].Confidence /= sum
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Confidence > scores[j].Confidence })
+	return scores
+}
+
+// Detect returns the most likely language of text and its confidence, or
+// sonic.LangAutoDetect and 0 if text has no letters.
+func (d *Detector) Detect(text string) (sonic.Lang, float64) {
+	scores := d.Scores(text)
+	if len(scores) == 0 {
+		return sonic.LangAutoDetect, 0
+	}
+	return scores[0].Lang, scores[0].Confidence
+}
+
+// DetectLang returns the most likely language of text, or
+// sonic.LangAutoDetect if its confidence is below Options.MinConfidence.
+// It implements sonic.LangDetector.
+func (d *Detector) DetectLang(text string) sonic.Lang {
+	lang, confidence := d.Detect(text)
+	if confidence < d.opt.MinConfidence {
+		return sonic.LangAutoDetect
+	}
+	return lang
+}
+
+// ngrams returns the 1 to 3 character n-grams of the lowercased words of
+// text, padded with a space on both sides so word boundaries are taken
+// into account.
+func ngrams(text string) []string {
+	var grams []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := make([]rune, 0, len(word)+2)
+		runes = append(runes, ' ')
+		for _, r := range word {
+			runes = append(runes, unicode.ToLower(r))
+		}
+		runes = append(runes, ' ')
+
+		for n := 1; n <= maxN; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if n == 1 && runes[i] == ' ' {
+					continue
+				}
+				grams = append(grams, string(runes[i:i+n]))
+			}
+		}
+	}
+	return grams
+}
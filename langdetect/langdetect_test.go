@@ -0,0 +1,120 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonic"
+)
+
+func newTestDetector(t *testing.T, opt *Options) *Detector {
+	t.Helper()
+
+	d, err := New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDetectLang(t *testing.T) {
+	d := newTestDetector(t, nil)
+
+	// None of these sentences is part of the samples.
+	tests := []struct {
+		text string
+		want sonic.Lang
+	}{
+		{"bugün hava çok güzel", sonic.LangTur},
+		{"akşam yemeğinden sonra sahilde yürüdük", sonic.LangTur},
+		{"where is the train station", sonic.LangEng},
+		{"the weather is nice today", sonic.LangEng},
+		{"das Wetter ist heute schön", sonic.LangDeu},
+		{"wir fahren morgen mit dem Zug nach Berlin", sonic.LangDeu},
+		{"il fait beau aujourd'hui", sonic.LangFra},
+		{"nous partons demain matin avec nos enfants", sonic.LangFra},
+		{"dónde está la estación", sonic.LangSpa},
+		{"mañana vamos a la playa con mis hermanos", sonic.LangSpa},
+		{"dov'è la stazione", sonic.LangIta},
+		{"domani andiamo al mare con i bambini", sonic.LangIta},
+		{"onde fica a estação", sonic.LangPor},
+		{"amanhã vamos à praia com os nossos filhos", sonic.LangPor},
+		{"het weer is mooi vandaag", sonic.LangNld},
+		{"morgen gaan we met de kinderen naar het strand", sonic.LangNld},
+	}
+	for _, tt := range tests {
+		if got := d.DetectLang(tt.text); got != tt.want {
+			lang, confidence := d.Detect(tt.text)
+			t.Errorf("DetectLang(%q) = %q, want %q (detected %q with %.2f)",
+				tt.text, got, tt.want, lang, confidence)
+		}
+	}
+}
+
+func TestDetectLangMinConfidence(t *testing.T) {
+	// Short texts that look English to the n-grams, but only barely.
+	texts := []string{"istasyon nerede", "hello"}
+
+	tests := []struct {
+		name          string
+		minConfidence float64
+		want          sonic.Lang
+	}{
+		{"default", 0, sonic.LangAutoDetect},
+		{"0.9", 0.9, sonic.LangAutoDetect},
+		{"0.2", 0.2, sonic.LangEng},
+		{"disabled", -1, sonic.LangEng},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDetector(t, &Options{MinConfidence: tt.minConfidence})
+			for _, text := range texts {
+				if got := d.DetectLang(text); got != tt.want {
+					_, confidence := d.Detect(text)
+					t.Errorf("DetectLang(%q) = %q, want %q (confidence %.2f)",
+						text, got, tt.want, confidence)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectNoLetters(t *testing.T) {
+	d := newTestDetector(t, nil)
+
+	for _, text := range []string{"", "  ", "1234 5678", "?!"} {
+		lang, confidence := d.Detect(text)
+		if lang != sonic.LangAutoDetect || confidence != 0 {
+			t.Errorf("Detect(%q) = %q, %v, want %q, 0", text, lang, confidence, sonic.LangAutoDetect)
+		}
+	}
+}
+
+func TestScores(t *testing.T) {
+	d := newTestDetector(t, &Options{Langs: []sonic.Lang{sonic.LangDeu, sonic.LangNld}})
+
+	scores := d.Scores("the weather is nice today")
+	if len(scores) != 2 {
+		t.Fatalf("got %d scores, want 2", len(scores))
+	}
+
+	var sum float64
+	for i, score := range scores {
+		if score.Lang != sonic.LangDeu && score.Lang != sonic.LangNld {
+			t.Errorf("got lang %q outside of Options.Langs", score.Lang)
+		}
+		if i > 0 && score.Confidence > scores[i-1].Confidence {
+			t.Errorf("scores not sorted: %v", scores)
+		}
+		sum += score.Confidence
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("confidences add up to %v, want 1", sum)
+	}
+}
+
+func TestNewUnknownLang(t *testing.T) {
+	_, err := New(&Options{Langs: []sonic.Lang{sonic.LangEng, sonic.LangJpn}})
+	if err == nil {
+		t.Fatal("New accepted a lang without a profile")
+	}
+}
@@ -20,7 +20,10 @@ type Conn struct {
 	bw *bufio.Writer
 	wr *proto.Writer
 
-	Inited    bool
+	Inited bool
+	// Maximum command line size negotiated with START, 0 if unknown.
+	BufferSize int
+
 	pooled    bool
 	createdAt time.Time
 }
@@ -3,6 +3,7 @@ package proto
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -19,6 +20,8 @@ const (
 	PendingReply   = "PENDING"
 	EventReply     = "EVENT"
 	QueryReply     = "QUERY"
+	SuggestReply   = "SUGGEST"
+	ListReply      = "LIST"
 	ResultReply    = "RESULT"
 	OkReply        = "OK"
 	EndedReply     = "ENDED"
@@ -26,12 +29,89 @@ const (
 
 //------------------------------------------------------------------------------
 
+// Kinds of Sonic server errors, matched with errors.Is. Errors with a code
+// missing from errorKinds, such as internal_error or shutting_down, match
+// ErrServer.
+var (
+	ErrInvalidFormat  = errors.New("sonic: invalid format")
+	ErrUnknownCommand = errors.New("sonic: unknown command")
+	ErrNotFound       = errors.New("sonic: not found")
+	ErrQueryTooLong   = errors.New("sonic: query too long")
+	ErrQuery          = errors.New("sonic: query error")
+	ErrPolicyReject   = errors.New("sonic: rejected by policy")
+	ErrAuthFailed     = errors.New("sonic: authentication failed")
+	ErrServer         = errors.New("sonic: server error")
+)
+
+var errorKinds = map[string]error{
+	"invalid_format":          ErrInvalidFormat,
+	"invalid_meta_key":        ErrInvalidFormat,
+	"invalid_meta_value":      ErrInvalidFormat,
+	"unknown_command":         ErrUnknownCommand,
+	"not_recognized":          ErrUnknownCommand,
+	"not_found":               ErrNotFound,
+	"query_too_long":          ErrQueryTooLong,
+	"text_too_long":           ErrQueryTooLong,
+	"line_too_long":           ErrQueryTooLong,
+	"buffer_overflow":         ErrQueryTooLong,
+	"query_error":             ErrQuery,
+	"policy_reject":           ErrPolicyReject,
+	"authentication_failed":   ErrAuthFailed,
+	"authentication_required": ErrAuthFailed,
+}
+
+// Codes of errors caused by the server state rather than by the command.
+var temporaryCodes = map[string]bool{
+	"internal_error":                true,
+	"shutting_down":                 true,
+	"timed_out":                     true,
+	"max number of clients reached": true,
+}
+
+// SonicError is an ERR reply, e.g. ERR invalid_format(PUSH <collection> <bucket> <object> "<text>"),
+// or an ENDED reply closing the connection instead of the expected reply,
+// e.g. ENDED authentication_failed.
 type SonicError string
 
 func (e SonicError) Error() string { return string(e) }
 
 func (SonicError) SonicError() {}
 
+// Code returns the error code, e.g. invalid_format.
+func (e SonicError) Code() string {
+	code := string(e)
+	for _, prefix := range []string{ErrorReply + " ", EndedReply + " "} {
+		code = strings.TrimPrefix(code, prefix)
+	}
+	if i := strings.IndexByte(code, '('); i >= 0 {
+		code = code[:i]
+	}
+	return strings.TrimSpace(code)
+}
+
+// Detail returns the text between parentheses after the code, if any.
+func (e SonicError) Detail() string {
+	s := string(e)
+	i := strings.IndexByte(s, '(')
+	if i < 0 || !strings.HasSuffix(s, ")") {
+		return ""
+	}
+	return s[i+1 : len(s)-1]
+}
+
+// Unwrap returns the kind of the error, or ErrServer for unknown codes.
+func (e SonicError) Unwrap() error {
+	if kind, ok := errorKinds[e.Code()]; ok {
+		return kind
+	}
+	return ErrServer
+}
+
+// Temporary reports whether the same command may succeed when retried.
+func (e SonicError) Temporary() bool {
+	return temporaryCodes[e.Code()]
+}
+
 //------------------------------------------------------------------------------
 
 type MultiBulkParse func(*Reader, int64) (interface{}, error)
@@ -94,7 +174,6 @@ func (r *Reader) readLine() ([]byte, error) {
 		return nil, fmt.Errorf("sonic: invalid reply: %q", b)
 	}
 
-	fmt.Printf("Readline: %s\n", string(b[:len(b)-2]))
 	return b[:len(b)-2], nil
 }
 
@@ -108,7 +187,6 @@ func (r *Reader) ReadReply(m MultiBulkParse, marker string) (interface{}, error)
 	w.Split(bufio.ScanWords)
 	w.Scan()
 
-	fmt.Printf("BASE Find: %s\n", w.Text())
 	switch w.Text() {
 	case ErrorReply:
 		return nil, SonicError(string(line[:]))
@@ -124,35 +202,14 @@ func (r *Reader) ReadReply(m MultiBulkParse, marker string) (interface{}, error)
 			return false
 		})
 
-		fmt.Printf("StartedReply Buffer: %s\n", ss[len(ss)-1])
 		return strconv.ParseInt(ss[len(ss)-1], 10, 64)
 	case PongReply:
 		return string(line), nil
 	case EventReply:
-		if marker != "" && strings.HasPrefix(string(line), "EVENT QUERY "+marker) {
-			// Make Slice
-			results := []string{}
-			result := strings.Replace(string(line), "EVENT QUERY "+marker+" ", "", 1)
-			if len(result) == 0 {
-				return results, nil
-			}
-
-			results = strings.Split(result, " ")
-			if len(results) > 0 {
-				return results, nil
-			}
-		} else if marker != "" && strings.HasPrefix(string(line), "EVENT SUGGEST "+marker) {
-			// Make Slice
-			results := []string{}
-			result := strings.Replace(string(line), "EVENT SUGGEST "+marker+" ", "", 1)
-			if len(result) == 0 {
-				return results, nil
-			}
-
-			results = strings.Split(result, " ")
-			if len(results) > 0 {
-				return results, nil
-			}
+		// EVENT <QUERY|SUGGEST|LIST> <marker> [results...]
+		fields := strings.Fields(string(line))
+		if marker != "" && len(fields) >= 3 && fields[2] == marker {
+			return append([]string{}, fields[3:]...), nil
 		}
 
 		return nil, fmt.Errorf("sonic: conn ended marker %s not found", marker)
@@ -178,24 +235,111 @@ func (r *Reader) ReadReply(m MultiBulkParse, marker string) (interface{}, error)
 }
 
 func (r *Reader) ReadIntReply() (int64, error) {
-	line, err := r.ReadLine()
+	kind, line, err := r.readReplyLine()
 	if err != nil {
 		return 0, err
 	}
-	w := bufio.NewScanner(bytes.NewBuffer(line))
-	w.Split(bufio.ScanWords)
-	w.Scan()
 
-	fmt.Printf("INT Find: %s\n", w.Text())
-	switch w.Text() {
-	case ErrorReply:
-		return 0, SonicError(string(line[:]))
+	switch kind {
 	case ResultReply:
 		results := strings.Split(string(line), " ")
 		return strconv.ParseInt(results[1], 10, 64)
 	case EndedReply:
 		return 0, nil
 	default:
-		return 0, fmt.Errorf("redis: can't parse int reply: %.100q", line)
+		return 0, fmt.Errorf("sonic: can't parse int reply: %.100q", line)
+	}
+}
+
+// ReadStatusReply reads an OK, PONG or ENDED reply and returns the whole line.
+func (r *Reader) ReadStatusReply() (string, error) {
+	kind, line, err := r.readReplyLine()
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case OkReply, PongReply, EndedReply:
+		return string(line), nil
+	default:
+		return "", fmt.Errorf("sonic: can't parse status reply: %.100q", line)
+	}
+}
+
+// ReadStartedReply reads a STARTED reply and returns the fields after
+// STARTED, e.g. [search protocol(1) buffer(20000)].
+func (r *Reader) ReadStartedReply() ([]string, error) {
+	return r.readFieldsReply(StartedReply)
+}
+
+// ReadResultReply reads a RESULT reply and returns the fields after RESULT.
+func (r *Reader) ReadResultReply() ([]string, error) {
+	return r.readFieldsReply(ResultReply)
+}
+
+func (r *Reader) readFieldsReply(want string) ([]string, error) {
+	kind, line, err := r.readReplyLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == EndedReply && len(line) > len(EndedReply) {
+		// The server closes the connection with the reason, e.g. a wrong
+		// START password.
+		return nil, SonicError(string(line))
+	}
+	if kind != want {
+		return nil, fmt.Errorf("sonic: can't parse %s reply: %.100q", strings.ToLower(want), line)
+	}
+	return strings.Fields(string(line))[1:], nil
+}
+
+// ReadEventReply reads the PENDING marker of a search command and then the
+// EVENT reply carrying the same marker, returning the results of the event.
+func (r *Reader) ReadEventReply(event string) ([]string, error) {
+	kind, line, err := r.readReplyLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if kind != PendingReply {
+		return nil, fmt.Errorf("sonic: can't parse pending reply: %.100q", line)
+	}
+	marker := strings.TrimSpace(string(line[len(PendingReply):]))
+
+	_, line, err = r.readReplyLine()
+	if err != nil {
+		return nil, err
+	}
+
+	// EVENT <event> <marker> [results...]
+	fields := strings.Fields(string(line))
+	if len(fields) < 3 || fields[0] != EventReply || fields[1] != event || fields[2] != marker {
+		return nil, fmt.Errorf("sonic: conn ended marker %s not found", marker)
+	}
+	return fields[3:], nil
+}
+
+// readReplyLine reads the next reply line, skipping the CONNECTED banner
+// and turning ERR replies into SonicError.
+func (r *Reader) readReplyLine() (string, []byte, error) {
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return "", nil, err
+		}
+
+		kind := string(line)
+		if i := bytes.IndexByte(line, ' '); i >= 0 {
+			kind = string(line[:i])
+		}
+
+		switch kind {
+		case ConnectedReply:
+			continue
+		case ErrorReply:
+			return kind, line, SonicError(string(line))
+		}
+		return kind, line, nil
 	}
 }
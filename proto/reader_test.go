@@ -0,0 +1,64 @@
+package proto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSonicError(t *testing.T) {
+	tests := []struct {
+		line      string
+		code      string
+		detail    string
+		kind      error
+		temporary bool
+	}{
+		{
+			line:   "ERR invalid_format(PUSH <collection> <bucket> <object> \"<text>\")",
+			code:   "invalid_format",
+			detail: "PUSH <collection> <bucket> <object> \"<text>\"",
+			kind:   ErrInvalidFormat,
+		},
+		{line: "ERR invalid_meta_key(LIMT[10])", code: "invalid_meta_key", detail: "LIMT[10]", kind: ErrInvalidFormat},
+		{line: "ERR unknown_command", code: "unknown_command", kind: ErrUnknownCommand},
+		{line: "ERR not_found", code: "not_found", kind: ErrNotFound},
+		{line: "ERR buffer_overflow", code: "buffer_overflow", kind: ErrQueryTooLong},
+		{line: "ERR query_error", code: "query_error", kind: ErrQuery},
+		{line: "ERR policy_reject(limit too high)", code: "policy_reject", detail: "limit too high", kind: ErrPolicyReject},
+		{line: "ERR authentication_failed", code: "authentication_failed", kind: ErrAuthFailed},
+		{line: "ENDED authentication_failed", code: "authentication_failed", kind: ErrAuthFailed},
+		{line: "ENDED authentication_required", code: "authentication_required", kind: ErrAuthFailed},
+		{line: "ERR internal_error", code: "internal_error", kind: ErrServer, temporary: true},
+		{line: "ERR shutting_down", code: "shutting_down", kind: ErrServer, temporary: true},
+		{line: "ERR max number of clients reached", code: "max number of clients reached", kind: ErrServer, temporary: true},
+		{line: "ERR brand_new_code(something)", code: "brand_new_code", detail: "something", kind: ErrServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			// Read the error as the client does, ENDED closing the
+			// connection instead of STARTED.
+			_, err := NewReader(strings.NewReader(tt.line + "\r\n")).ReadStartedReply()
+
+			var serr SonicError
+			if !errors.As(err, &serr) {
+				t.Fatalf("got %v, want a SonicError", err)
+			}
+			if got := serr.Code(); got != tt.code {
+				t.Errorf("Code() = %q, want %q", got, tt.code)
+			}
+			if got := serr.Detail(); got != tt.detail {
+				t.Errorf("Detail() = %q, want %q", got, tt.detail)
+			}
+			if got := serr.Unwrap(); got != tt.kind {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.kind)
+			}
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.kind)
+			}
+			if got := serr.Temporary(); got != tt.temporary {
+				t.Errorf("Temporary() = %v, want %v", got, tt.temporary)
+			}
+		})
+	}
+}
@@ -23,3 +23,51 @@ func (w *Writer) WriteArgs(args []string) error {
 	_, err := w.writer.Write([]byte(strings.Join(args, " ") + "\r\n"))
 	return err
 }
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\r", `\r`,
+	"\n", `\n`,
+)
+
+// QuoteText escapes text so it can't break out of the quoted text argument
+// of PUSH, POP, QUERY and SUGGEST or end the command line, and wraps it in
+// double quotes.
+func QuoteText(text string) string {
+	return `"` + textEscaper.Replace(text) + `"`
+}
+
+// UnquoteText reverses QuoteText. Unknown escape sequences are kept as is.
+func UnquoteText(text string) string {
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		text = text[1 : len(text)-1]
+	}
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '\\' || i == len(text)-1 {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch text[i] {
+		case '\\', '"':
+			b.WriteByte(text[i])
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
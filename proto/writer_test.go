@@ -0,0 +1,50 @@
+package proto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // quoted form
+	}{
+		{"empty", "", `""`},
+		{"plain", "hello world", `"hello world"`},
+		{"quotes", `say "hi"`, `"say \"hi\""`},
+		{"lone quote", `"`, `"\""`},
+		{"backslash", `C:\dir\file`, `"C:\\dir\\file"`},
+		{"trailing backslash", `end\`, `"end\\"`},
+		{"escaped quote", `\"`, `"\\\""`},
+		{"escape lookalike", `\n is not a newline`, `"\\n is not a newline"`},
+		{"crlf", "line1\r\nline2", `"line1\r\nline2"`},
+		{"lf only", "a\nb\n", `"a\nb\n"`},
+		{"cr only", "a\rb", `"a\rb"`},
+		{"multibyte", "çağrı 日本語 🙂", `"çağrı 日本語 🙂"`},
+		{"multibyte with quotes", `"ünlü" şarkı`, `"\"ünlü\" şarkı"`},
+		{"invalid utf8", "a\xffb\xc3", "\"a\xffb\xc3\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoted := QuoteText(tt.text)
+			if quoted != tt.want {
+				t.Fatalf("QuoteText(%q) = %q, want %q", tt.text, quoted, tt.want)
+			}
+			if strings.ContainsAny(quoted, "\r\n") {
+				t.Fatalf("QuoteText(%q) = %q holds a line ending", tt.text, quoted)
+			}
+			if got := UnquoteText(quoted); got != tt.text {
+				t.Fatalf("UnquoteText(%q) = %q, want %q", quoted, got, tt.text)
+			}
+		})
+	}
+}
+
+func TestUnquoteTextUnknownEscape(t *testing.T) {
+	if got := UnquoteText(`"a\tb"`); got != `a\tb` {
+		t.Fatalf("UnquoteText = %q, want %q", got, `a\tb`)
+	}
+}
@@ -0,0 +1,168 @@
+package sonic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// IngestResult is the outcome of a single IngestItem sent by MPush or MPop.
+type IngestResult struct {
+	Item IngestItem
+
+	// Number of commands the item text was split into.
+	Chunks int
+
+	Err error
+}
+
+// BatchError is returned by BatchCmd.Err when at least one item failed.
+type BatchError struct {
+	Failed int
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("sonic: %d of %d batch items failed", e.Failed, e.Total)
+}
+
+// BatchCmd holds the per-item report of a MPush or MPop call.
+type BatchCmd struct {
+	ctx context.Context
+	err error
+
+	val []IngestResult
+}
+
+func newBatchCmd(ctx context.Context, items []IngestItem) *BatchCmd {
+	val := make([]IngestResult, len(items))
+	for i, item := range items {
+		val[i].Item = item
+	}
+
+	return &BatchCmd{
+		ctx: ctx,
+		val: val,
+	}
+}
+
+func (cmd *BatchCmd) SetErr(e error) {
+	cmd.err = e
+}
+
+func (cmd *BatchCmd) Err() error {
+	return cmd.err
+}
+
+func (cmd *BatchCmd) Val() []IngestResult {
+	return cmd.val
+}
+
+func (cmd *BatchCmd) Result() ([]IngestResult, error) {
+	return cmd.val, cmd.err
+}
+
+// Failed returns only the results of the items that could not be ingested.
+func (cmd *BatchCmd) Failed() []IngestResult {
+	var failed []IngestResult
+	for _, res := range cmd.val {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// setBatchErr sets a *BatchError if any item failed.
+func (cmd *BatchCmd) setBatchErr() {
+	var failed int
+	for _, res := range cmd.val {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		cmd.SetErr(&BatchError{Failed: failed, Total: len(cmd.val)})
+	}
+}
+
+func (cmd *BatchCmd) String() string {
+	if cmd.err != nil {
+		return cmd.err.Error()
+	}
+	return fmt.Sprintf("%d items", len(cmd.val))
+}
+
+//------------------------------------------------------------------------------
+
+// processBatch sends every item as a chunked PUSH/POP command through
+// process, running at most concurrency items at the same time. With a pooled
+// process every worker uses its own connection.
+func processBatch(
+	ctx context.Context, process func(context.Context, Cmder) error,
+	command string, items []IngestItem, concurrency int,
+) *BatchCmd {
+	cmd := newBatchCmd(ctx, items)
+	if len(items) == 0 {
+		return cmd
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				res := &cmd.val[idx]
+				res.Chunks, res.Err = processItem(ctx, process, command, res.Item)
+			}
+		}()
+	}
+
+	for i := range items {
+		if ctx.Err() != nil {
+			cmd.val[i].Err = ctx.Err()
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	cmd.setBatchErr()
+	return cmd
+}
+
+func processItem(
+	ctx context.Context, process func(context.Context, Cmder) error,
+	command string, item IngestItem,
+) (int, error) {
+	qb := NewQueryBuilder()
+	qb.Command = command
+	qb.Collection = item.Collection
+	qb.Bucket = item.Bucket
+	qb.Object = item.Object
+	qb.Text = item.Item
+
+	if command == CmdIngestPush {
+		if err := item.Lang.validate(); err != nil {
+			return 0, err
+		}
+		qb.Lang = string(item.Lang)
+		cmd := newPushCmd(ctx, qb)
+		err := process(ctx, cmd)
+		return cmd.Chunks(), err
+	}
+
+	cmd := newPopCmd(ctx, qb)
+	err := process(ctx, cmd)
+	return cmd.Chunks(), err
+}
@@ -0,0 +1,95 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+func TestMPushMPop(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := NewClient(&Options{
+		Addr:             srv.Addr,
+		ChannelMode:      ChannelIngest,
+		PoolSize:         4,
+		BatchConcurrency: 4,
+	})
+	defer ingest.Close()
+
+	const n = 30
+	items := make([]IngestItem, n)
+	for i := range items {
+		items[i] = IngestItem{
+			Collection: "c",
+			Bucket:     "b",
+			Object:     fmt.Sprintf("obj:%d", i),
+			Item:       fmt.Sprintf("word%d common", i),
+			Lang:       LangEng,
+		}
+	}
+	items[7].Lang = "xxx"
+
+	cmd := ingest.MPush(ctx, items)
+	var batchErr *BatchError
+	if !errors.As(cmd.Err(), &batchErr) || batchErr.Failed != 1 || batchErr.Total != n {
+		t.Fatalf("MPush error = %v, want 1 of %d failed", cmd.Err(), n)
+	}
+	failed := cmd.Failed()
+	if len(failed) != 1 || failed[0].Item.Object != "obj:7" || !errors.Is(failed[0].Err, ErrUnknownLang) {
+		t.Fatalf("Failed() = %+v", failed)
+	}
+	for i, res := range cmd.Val() {
+		if res.Item != items[i] {
+			t.Fatalf("result %d is for %+v, want %+v", i, res.Item, items[i])
+		}
+		if i != 7 && (res.Err != nil || res.Chunks != 1) {
+			t.Fatalf("result %d = %+v", i, res)
+		}
+	}
+	if words := srv.Words("c", "b", "obj:3"); !reflect.DeepEqual(words, []string{"common", "word3"}) {
+		t.Fatalf("obj:3 words = %q", words)
+	}
+	if words := srv.Words("c", "b", "obj:7"); len(words) != 0 {
+		t.Fatalf("obj:7 words = %q, want none", words)
+	}
+
+	if err := ingest.MPop(ctx, items[:10]).Err(); err != nil {
+		t.Fatalf("MPop: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if words := srv.Words("c", "b", items[i].Object); len(words) != 0 {
+			t.Fatalf("%s words = %q after MPop", items[i].Object, words)
+		}
+	}
+	if words := srv.Words("c", "b", "obj:10"); len(words) == 0 {
+		t.Fatal("MPop removed an item it was not given")
+	}
+}
+
+func TestMPushSplitsText(t *testing.T) {
+	ctx := context.Background()
+	srv := sonictest.NewUnstartedServer()
+	srv.BufferSize = 128
+	srv.Start()
+	defer srv.Close()
+
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+
+	text := strings.Repeat("lorem ipsum dolor ", 40)
+	cmd := ingest.MPush(ctx, []IngestItem{{Collection: "c", Bucket: "b", Object: "o", Item: text}})
+	if err := cmd.Err(); err != nil {
+		t.Fatalf("MPush: %v", err)
+	}
+	if chunks := cmd.Val()[0].Chunks; chunks < 2 {
+		t.Fatalf("text sent in %d chunk", chunks)
+	}
+	if words := srv.Words("c", "b", "o"); !reflect.DeepEqual(words, []string{"dolor", "ipsum", "lorem"}) {
+		t.Fatalf("words = %q", words)
+	}
+}
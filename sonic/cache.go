@@ -0,0 +1,250 @@
+package sonic
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryCacheOptions keeps the settings of a QueryCache.
+type QueryCacheOptions struct {
+	// Maximum number of cached results, the least recently used
+	// is evicted first.
+	// Default is 1000.
+	Size int
+
+	// Time a cached result is served for.
+	// Default is 1 minute.
+	TTL time.Duration
+}
+
+func (opt *QueryCacheOptions) init() {
+	if opt.Size == 0 {
+		opt.Size = 1000
+	}
+	if opt.TTL == 0 {
+		opt.TTL = time.Minute
+	}
+}
+
+// CacheStats contains query cache statistics.
+type CacheStats struct {
+	Hits          uint32 // number of times a result was found in the cache
+	Misses        uint32 // number of times a result was NOT found in the cache
+	Evictions     uint32 // number of results removed for space or expired
+	Invalidations uint32 // number of results removed by ingest commands
+
+	Len uint32 // number of cached results
+}
+
+type cacheEntry struct {
+	key        string
+	collection string
+	bucket     string
+	val        []string
+	expiresAt  time.Time
+}
+
+// QueryCache caches the results of QUERY and SUGGEST commands, keyed by
+// their encoded arguments. PUSH, POP and FLUSH* commands sent by a client
+// using the same cache invalidate the results of the collection or bucket
+// they change, so the search and ingest clients of a process should share
+// it through Options.QueryCache. Changes made by other processes are only
+// seen once results expire. It's safe for concurrent use by multiple
+// goroutines.
+type QueryCache struct {
+	opt *QueryCacheOptions
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+	buckets map[string]map[string]map[*list.Element]struct{} // collection -> bucket -> entries
+	// Incremented by every invalidation, so results read before it
+	// are not stored after it.
+	gen uint64
+
+	stats CacheStats
+}
+
+func NewQueryCache(opt *QueryCacheOptions) *QueryCache {
+	if opt == nil {
+		opt = &QueryCacheOptions{}
+	}
+	opt.init()
+
+	return &QueryCache{
+		opt:     opt,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		buckets: make(map[string]map[string]map[*list.Element]struct{}),
+	}
+}
+
+// Stats returns query cache stats.
+func (c *QueryCache) Stats() *CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Len = uint32(c.ll.Len())
+	return &stats
+}
+
+// Invalidate removes the cached results of a bucket, or of every bucket of
+// the collection if bucket is empty.
+func (c *QueryCache) Invalidate(collection, bucket string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	buckets, ok := c.buckets[collection]
+	if !ok {
+		return
+	}
+	for name, elems := range buckets {
+		if bucket != "" && name != bucket {
+			continue
+		}
+		for el := range elems {
+			c.removeElement(el)
+			c.stats.Invalidations++
+		}
+	}
+}
+
+// Purge removes every cached result.
+func (c *QueryCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.ll.Init()
+	c.entries = make(map[string]*list.Element)
+	c.buckets = make(map[string]map[string]map[*list.Element]struct{})
+}
+
+func (c *QueryCache) get(key string) ([]string, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if ok && time.Now().After(el.Value.(*cacheEntry).expiresAt) {
+		c.removeElement(el)
+		c.stats.Evictions++
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, c.gen, false
+	}
+
+	c.stats.Hits++
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).val, c.gen, true
+}
+
+// set stores a result read while the cache was at generation gen.
+func (c *QueryCache) set(key, collection, bucket string, val []string, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+
+	el := c.ll.PushFront(&cacheEntry{
+		key:        key,
+		collection: collection,
+		bucket:     bucket,
+		val:        val,
+		expiresAt:  time.Now().Add(c.opt.TTL),
+	})
+	c.entries[key] = el
+
+	buckets, ok := c.buckets[collection]
+	if !ok {
+		buckets = make(map[string]map[*list.Element]struct{})
+		c.buckets[collection] = buckets
+	}
+	elems, ok := buckets[bucket]
+	if !ok {
+		elems = make(map[*list.Element]struct{})
+		buckets[bucket] = elems
+	}
+	elems[el] = struct{}{}
+
+	for c.ll.Len() > c.opt.Size {
+		c.removeElement(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *QueryCache) removeElement(el *list.Element) {
+	entry := c.ll.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+
+	buckets := c.buckets[entry.collection]
+	delete(buckets[entry.bucket], el)
+	if len(buckets[entry.bucket]) == 0 {
+		delete(buckets, entry.bucket)
+	}
+	if len(buckets) == 0 {
+		delete(c.buckets, entry.collection)
+	}
+}
+
+type stringSliceCmd interface {
+	Cmder
+	SetVal([]string)
+	Val() []string
+}
+
+// process serves QUERY and SUGGEST from the cache, or runs them with
+// process and caches the result, and invalidates the results changed by
+// ingest commands.
+func (c *QueryCache) process(ctx context.Context, cmd Cmder, process func(context.Context, Cmder) error) error {
+	name := strings.ToUpper(cmd.Name())
+	args := cmd.Args()
+
+	if cmd, ok := cmd.(stringSliceCmd); ok && (name == CmdSearchQuery || name == CmdSearchSuggest) && len(args) > 2 {
+		key := strings.Join(args, " ")
+		val, gen, ok := c.get(key)
+		if ok {
+			cmd.SetVal(append([]string(nil), val...))
+			return nil
+		}
+
+		if err := process(ctx, cmd); err != nil {
+			return err
+		}
+		c.set(key, args[1], args[2], append([]string(nil), cmd.Val()...), gen)
+		return nil
+	}
+
+	err := process(ctx, cmd)
+	c.invalidateCmd(cmd)
+	return err
+}
+
+// invalidateCmd invalidates the results changed by an ingest command, even
+// a failed one since some of its chunks may have been applied.
+func (c *QueryCache) invalidateCmd(cmd Cmder) {
+	args := cmd.Args()
+	switch strings.ToUpper(cmd.Name()) {
+	case CmdIngestFlushc:
+		if len(args) > 1 {
+			c.Invalidate(args[1], "")
+		}
+	case CmdIngestPush, CmdIngestPop, CmdIngestFlushb, CmdIngestFlusho:
+		if len(args) > 2 {
+			c.Invalidate(args[1], args[2])
+		}
+	}
+}
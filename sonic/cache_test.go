@@ -0,0 +1,88 @@
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestQueryCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	cache := NewQueryCache(nil)
+
+	ingest := NewClient(&Options{Addr: srv.Addr, ChannelMode: ChannelIngest, QueryCache: cache})
+	defer ingest.Close()
+	search := NewClient(&Options{Addr: srv.Addr, ChannelMode: ChannelSearch, QueryCache: cache})
+	defer search.Close()
+	// Not sharing the cache, its changes are not seen until invalidated.
+	other := newTestClient(t, srv.Addr, ChannelIngest)
+
+	query := func(bucket string, want ...string) {
+		t.Helper()
+		ids, err := search.Query(ctx, "c", bucket, "hello", 10, 0, LangNone).Result()
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(ids) != len(want) || (len(want) > 0 && !reflect.DeepEqual(ids, want)) {
+			t.Fatalf("Query(%s) = %q, want %q", bucket, ids, want)
+		}
+	}
+	push := func(client *Client, bucket, object string) {
+		t.Helper()
+		if err := client.Push(ctx, "c", bucket, object, "hello", LangNone).Err(); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	push(ingest, "b1", "obj:1")
+	push(ingest, "b2", "obj:1")
+	query("b1", "obj:1")
+	query("b2", "obj:1")
+	query("b1", "obj:1")
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 || stats.Len != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// PUSH only invalidates its own bucket.
+	push(ingest, "b1", "obj:2")
+	query("b1", "obj:2", "obj:1")
+	push(other, "b2", "obj:2")
+	query("b2", "obj:1")
+
+	// POP and FLUSHO invalidate, from a pipeline too.
+	if err := ingest.Pop(ctx, "c", "b1", "obj:2", "hello").Err(); err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+	query("b1", "obj:1")
+	if _, err := ingest.Pipelined(ctx, func(pipe Pipeliner) error {
+		pipe.FlushObject(ctx, "c", "b1", "obj:1")
+		return nil
+	}); err != nil {
+		t.Fatalf("Pipelined: %v", err)
+	}
+	query("b1")
+
+	// FLUSHB invalidates its bucket and FLUSHC every bucket.
+	push(ingest, "b1", "obj:3")
+	query("b1", "obj:3")
+	if err := ingest.FlushBucket(ctx, "c", "b1").Err(); err != nil {
+		t.Fatalf("FlushBucket: %v", err)
+	}
+	query("b1")
+	query("b2", "obj:1")
+	if err := ingest.FlushCollection(ctx, "c").Err(); err != nil {
+		t.Fatalf("FlushCollection: %v", err)
+	}
+	query("b2")
+
+	// Changes of other processes need an explicit invalidation.
+	push(other, "b2", "obj:4")
+	query("b2")
+	cache.Invalidate("c", "b2")
+	query("b2", "obj:4")
+
+	if stats := cache.Stats(); stats.Invalidations == 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/uretgec/go-sonic/proto"
@@ -22,7 +23,29 @@ type Cmder interface {
 	Err() error
 }
 
-func writeCmd(wr *proto.Writer, cmd Cmder) error {
+// chunkedCmder is implemented by commands whose text is split into several
+// command lines sized against the buffer negotiated on the connection.
+type chunkedCmder interface {
+	Cmder
+
+	// chunk splits the command for bufferSize and returns the lines to write.
+	chunk(bufferSize int) ([][]string, error)
+}
+
+func writeCmd(wr *proto.Writer, cmd Cmder, bufferSize int) error {
+	if cmd, ok := cmd.(chunkedCmder); ok {
+		lines, err := cmd.chunk(bufferSize)
+		if err != nil {
+			return err
+		}
+		for _, args := range lines {
+			if err := wr.WriteArgs(args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	return wr.WriteArgs(cmd.Args())
 }
 
@@ -37,6 +60,10 @@ func cmdString(cmd Cmder, val interface{}) string {
 			return string(v)
 		case int:
 			return strconv.Itoa(v)
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case []string:
+			return strings.Join(v, " ")
 		}
 	}
 
@@ -222,7 +249,6 @@ func (cmd *Cmd) BoolSlice() ([]bool, error) {
 }
 
 func (cmd *Cmd) readReply(rd *proto.Reader) (err error) {
-	fmt.Printf("BASE Komut: %s - CMD: %v\n", cmd.args[0], cmd.args)
 	cmd.val, err = rd.ReadReply(sliceParser, "")
 	return err
 }
@@ -280,7 +306,468 @@ func (cmd *IntCmd) String() string {
 }
 
 func (cmd *IntCmd) readReply(rd *proto.Reader) (err error) {
-	fmt.Printf("INT Komut: %s - CMD: %v\n", cmd.args[0], cmd.args)
 	cmd.val, err = rd.ReadIntReply()
 	return err
 }
+
+//------------------------------------------------------------------------------
+
+// StatusCmd is a command answered with OK, PONG or ENDED.
+type StatusCmd struct {
+	baseCmd
+
+	val string
+}
+
+var _ Cmder = (*StatusCmd)(nil)
+
+func NewStatusCmd(ctx context.Context, args ...string) *StatusCmd {
+	return &StatusCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *StatusCmd) SetVal(val string) {
+	cmd.val = val
+}
+
+func (cmd *StatusCmd) Val() string {
+	return cmd.val
+}
+
+func (cmd *StatusCmd) Result() (string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *StatusCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+func (cmd *StatusCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadStatusReply()
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// StartInfo is the reply of START, e.g. STARTED search protocol(1) buffer(20000).
+type StartInfo struct {
+	Mode     string
+	Protocol int
+
+	// Maximum size of a command line accepted by the server.
+	Buffer int
+}
+
+type StartCmd struct {
+	baseCmd
+
+	val StartInfo
+}
+
+var _ Cmder = (*StartCmd)(nil)
+
+func NewStartCmd(ctx context.Context, args ...string) *StartCmd {
+	return &StartCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *StartCmd) SetVal(val StartInfo) {
+	cmd.val = val
+}
+
+func (cmd *StartCmd) Val() StartInfo {
+	return cmd.val
+}
+
+func (cmd *StartCmd) Result() (StartInfo, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *StartCmd) String() string {
+	if cmd.err != nil {
+		return cmd.err.Error()
+	}
+	return fmt.Sprintf("%s protocol(%d) buffer(%d)", cmd.val.Mode, cmd.val.Protocol, cmd.val.Buffer)
+}
+
+func (cmd *StartCmd) readReply(rd *proto.Reader) error {
+	fields, err := rd.ReadStartedReply()
+	if err != nil {
+		return err
+	}
+
+	var val StartInfo
+	if len(fields) > 0 {
+		val.Mode = fields[0]
+	}
+	values := parseValues(fields)
+	if v, ok := values["protocol"]; ok {
+		if val.Protocol, err = strconv.Atoi(v); err != nil {
+			return err
+		}
+	}
+	if v, ok := values["buffer"]; ok {
+		if val.Buffer, err = strconv.Atoi(v); err != nil {
+			return err
+		}
+	}
+
+	cmd.val = val
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// QueryCmd is a QUERY command returning the matching object identifiers.
+type QueryCmd struct {
+	baseCmd
+
+	qb      QueryBuilder
+	val     []string
+	process cmdable
+}
+
+var _ Cmder = (*QueryCmd)(nil)
+
+func NewQueryCmd(ctx context.Context, args ...string) *QueryCmd {
+	return &QueryCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func newQueryCmd(ctx context.Context, process cmdable, qb QueryBuilder) *QueryCmd {
+	return &QueryCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: qb.Encode(),
+		},
+		qb:      qb,
+		process: process,
+	}
+}
+
+func (cmd *QueryCmd) SetVal(val []string) {
+	cmd.val = val
+}
+
+func (cmd *QueryCmd) Val() []string {
+	return cmd.val
+}
+
+func (cmd *QueryCmd) Result() ([]string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *QueryCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+// Iterator creates a new QueryIterator that walks the results past the
+// command's page, fetching the following pages of its LIMIT as needed and
+// stopping after max results if max is positive.
+func (cmd *QueryCmd) Iterator(max int) *QueryIterator {
+	return &QueryIterator{
+		cmd:  cmd,
+		max:  max,
+		seen: make(map[string]struct{}),
+	}
+}
+
+func (cmd *QueryCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadEventReply(proto.QueryReply)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// SuggestCmd is a SUGGEST command returning the completed words.
+type SuggestCmd struct {
+	baseCmd
+
+	val []string
+}
+
+var _ Cmder = (*SuggestCmd)(nil)
+
+func NewSuggestCmd(ctx context.Context, args ...string) *SuggestCmd {
+	return &SuggestCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *SuggestCmd) SetVal(val []string) {
+	cmd.val = val
+}
+
+func (cmd *SuggestCmd) Val() []string {
+	return cmd.val
+}
+
+func (cmd *SuggestCmd) Result() ([]string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *SuggestCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+func (cmd *SuggestCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadEventReply(proto.SuggestReply)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// ListCmd is a LIST command returning the words indexed in a bucket.
+type ListCmd struct {
+	baseCmd
+
+	qb      QueryBuilder
+	val     []string
+	process cmdable
+}
+
+var _ Cmder = (*ListCmd)(nil)
+
+func newListCmd(ctx context.Context, process cmdable, qb QueryBuilder) *ListCmd {
+	return &ListCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: qb.Encode(),
+		},
+		qb:      qb,
+		process: process,
+	}
+}
+
+func (cmd *ListCmd) SetVal(val []string) {
+	cmd.val = val
+}
+
+func (cmd *ListCmd) Val() []string {
+	return cmd.val
+}
+
+func (cmd *ListCmd) Result() ([]string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *ListCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+// Iterator creates a new ListIterator that walks every word of the bucket,
+// fetching the following pages of the command's LIMIT as needed.
+func (cmd *ListCmd) Iterator() *ListIterator {
+	return &ListIterator{
+		cmd: cmd,
+	}
+}
+
+func (cmd *ListCmd) readReply(rd *proto.Reader) (err error) {
+	cmd.val, err = rd.ReadEventReply(proto.ListReply)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// InfoCmd is an INFO command returning the parsed server statistics.
+type InfoCmd struct {
+	baseCmd
+
+	val *ServerInfo
+}
+
+var _ Cmder = (*InfoCmd)(nil)
+
+func NewInfoCmd(ctx context.Context, args ...string) *InfoCmd {
+	return &InfoCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *InfoCmd) SetVal(val *ServerInfo) {
+	cmd.val = val
+}
+
+func (cmd *InfoCmd) Val() *ServerInfo {
+	return cmd.val
+}
+
+func (cmd *InfoCmd) Result() (*ServerInfo, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *InfoCmd) String() string {
+	if cmd.err != nil {
+		return cmd.err.Error()
+	}
+	return fmt.Sprintf("%+v", cmd.val)
+}
+
+func (cmd *InfoCmd) readReply(rd *proto.Reader) error {
+	fields, err := rd.ReadResultReply()
+	if err != nil {
+		return err
+	}
+
+	cmd.val, err = parseServerInfo(parseValues(fields))
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// textCmd holds the text command of a PUSH or POP and the lines it was split
+// into when it was last written.
+type textCmd struct {
+	qb    QueryBuilder
+	lines [][]string
+}
+
+func (t *textCmd) chunk(bufferSize int) (lines [][]string, err error) {
+	t.lines, err = t.qb.EncodeChunks(bufferSize)
+	return t.lines, err
+}
+
+// Chunks returns the number of commands the text was split into.
+func (t *textCmd) Chunks() int {
+	return len(t.lines)
+}
+
+// PushCmd is a PUSH command whose text is split on word boundaries into as
+// many commands as the connection buffer requires. It succeeds only if
+// every chunk was pushed.
+type PushCmd struct {
+	baseCmd
+	textCmd
+
+	val string
+}
+
+var _ chunkedCmder = (*PushCmd)(nil)
+
+func newPushCmd(ctx context.Context, qb QueryBuilder) *PushCmd {
+	return &PushCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: qb.Encode(),
+		},
+		textCmd: textCmd{
+			qb: qb,
+		},
+	}
+}
+
+func (cmd *PushCmd) SetVal(val string) {
+	cmd.val = val
+}
+
+func (cmd *PushCmd) Val() string {
+	return cmd.val
+}
+
+func (cmd *PushCmd) Result() (string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *PushCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+func (cmd *PushCmd) readReply(rd *proto.Reader) error {
+	cmd.val = ""
+
+	// Every written line gets a reply, read them all to keep the
+	// connection in sync and report the first error.
+	var firstErr error
+	for range cmd.lines {
+		val, err := rd.ReadStatusReply()
+		if err != nil {
+			if !isSonicError(err) {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cmd.val = val
+	}
+	return firstErr
+}
+
+// PopCmd is a POP command whose text is split like PushCmd. Its value is
+// the total count reported by all chunks.
+type PopCmd struct {
+	baseCmd
+	textCmd
+
+	val int64
+}
+
+var _ chunkedCmder = (*PopCmd)(nil)
+
+func newPopCmd(ctx context.Context, qb QueryBuilder) *PopCmd {
+	return &PopCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: qb.Encode(),
+		},
+		textCmd: textCmd{
+			qb: qb,
+		},
+	}
+}
+
+func (cmd *PopCmd) SetVal(val int64) {
+	cmd.val = val
+}
+
+func (cmd *PopCmd) Val() int64 {
+	return cmd.val
+}
+
+func (cmd *PopCmd) Result() (int64, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *PopCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+func (cmd *PopCmd) readReply(rd *proto.Reader) error {
+	cmd.val = 0
+
+	var firstErr error
+	for range cmd.lines {
+		val, err := rd.ReadIntReply()
+		if err != nil {
+			if !isSonicError(err) {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cmd.val += val
+	}
+	return firstErr
+}
@@ -2,6 +2,7 @@ package sonic
 
 import (
 	"context"
+	"strings"
 )
 
 type IngestItem struct {
@@ -9,29 +10,30 @@ type IngestItem struct {
 	Bucket     string
 	Object     string
 	Item       string
-	Lang       string
+	Lang       Lang
 }
 
 // Base
 type BaseCmdable interface {
-	Ping(ctx context.Context) *Cmd
-	Quit(ctx context.Context) *Cmd
+	Ping(ctx context.Context) *StatusCmd
+	Quit(ctx context.Context) *StatusCmd
 }
 
 // ChannelMode: SEARCH
 type Cmdable interface {
-	Query(ctx context.Context, collection, bucket, terms string, limit, offset int, lang string) *Cmd
-	Suggest(ctx context.Context, collection, bucket, word string, limit int) *Cmd
+	Query(ctx context.Context, collection, bucket, terms string, limit, offset int, lang Lang) *QueryCmd
+	Suggest(ctx context.Context, collection, bucket, word string, limit int) *SuggestCmd
+	List(ctx context.Context, collection, bucket string, limit, offset int) *ListCmd
 
 	BaseCmdable
 }
 
 // ChannelMode: INGEST
 type IngestCmdable interface {
-	Push(ctx context.Context, collection, bucket, object, text, lang string) *Cmd
-	MPush(ctx context.Context, items []IngestItem) *Cmd
-	Pop(ctx context.Context, collection, bucket, object, text string) *Cmd
-	MPop(ctx context.Context, items []IngestItem) *Cmd
+	Push(ctx context.Context, collection, bucket, object, text string, lang Lang) *PushCmd
+	MPush(ctx context.Context, items []IngestItem) *BatchCmd
+	Pop(ctx context.Context, collection, bucket, object, text string) *PopCmd
+	MPop(ctx context.Context, items []IngestItem) *BatchCmd
 	Count(ctx context.Context, collection, bucket, object string) *IntCmd
 	FlushCollection(ctx context.Context, collection string) *IntCmd
 	FlushBucket(ctx context.Context, collection, bucket string) *IntCmd
@@ -42,21 +44,23 @@ type IngestCmdable interface {
 
 // ChannelMode: CONTROL
 type ControlCmdable interface {
-	Trigger(ctx context.Context, action, data string) *Cmd
-	Info(ctx context.Context) *Cmd
+	Trigger(ctx context.Context, action, data string) *StatusCmd
+	Info(ctx context.Context) *InfoCmd
 
 	BaseCmdable
 }
 
 // ChannelMode: Uninitialized
 type StatefulCmdable interface {
-	Start(ctx context.Context, channelMode, authPassword string) *Cmd
+	Start(ctx context.Context, channelMode, authPassword string) *StartCmd
 
 	BaseCmdable
 }
 
 var (
-	_ Cmdable = (*Client)(nil)
+	_ Cmdable       = (*Client)(nil)
+	_ IngestCmdable = (*Client)(nil)
+	_ IngestCmdable = (*Conn)(nil)
 )
 
 type baseCmdable func(ctx context.Context, cmd Cmder) error
@@ -65,11 +69,68 @@ type ingestCmdable func(ctx context.Context, cmd Cmder) error
 type controlCmdable func(ctx context.Context, cmd Cmder) error
 type statefulCmdable func(ctx context.Context, cmd Cmder) error
 
+// Channel modes each command can be sent on.
+var commandChannels = map[string][]string{
+	CmdPing: {ChannelSearch, ChannelIngest, ChannelControl},
+	CmdQuit: {ChannelSearch, ChannelIngest, ChannelControl},
+
+	CmdSearchStart: {ChannelUninitialized},
+
+	CmdSearchQuery:   {ChannelSearch},
+	CmdSearchSuggest: {ChannelSearch},
+	CmdSearchList:    {ChannelSearch},
+
+	CmdIngestPush:   {ChannelIngest},
+	CmdIngestPop:    {ChannelIngest},
+	CmdIngestCount:  {ChannelIngest},
+	CmdIngestFlushc: {ChannelIngest},
+	CmdIngestFlushb: {ChannelIngest},
+	CmdIngestFlusho: {ChannelIngest},
+
+	CmdControlTrigger: {ChannelControl},
+	CmdControlInfo:    {ChannelControl},
+}
+
+// CommandChannels returns the channel modes the command can be sent on,
+// or nil if the command is unknown to this client.
+func CommandChannels(name string) []string {
+	channels := commandChannels[strings.ToUpper(name)]
+	if channels == nil {
+		return nil
+	}
+	return append([]string(nil), channels...)
+}
+
+// CheckChannel returns a *ChannelError matching ErrWrongChannel if the
+// command can't be sent on the channel mode. Unknown commands are left
+// for the server to reject.
+func CheckChannel(name, mode string) error {
+	channels, ok := commandChannels[strings.ToUpper(name)]
+	if !ok || contains(channels, mode) {
+		return nil
+	}
+
+	return &ChannelError{
+		Command:  strings.ToUpper(name),
+		Channel:  mode,
+		Required: append([]string(nil), channels...),
+	}
+}
+
+// commandChannel returns the channel mode a command is sent on.
+// Unknown commands go to the search channel.
+func commandChannel(name string) string {
+	if channels := commandChannels[strings.ToUpper(name)]; len(channels) > 0 {
+		return channels[0]
+	}
+	return ChannelSearch
+}
+
 //------------------------------------------------------------------------------
 // QUERY <collection> <bucket> "<terms>" [LIMIT(<count>)]? [OFFSET(<count>)]? [LANG(<locale>)]?
 // Return PENDING SKblCsMz <- this is marker
 // After EVENT QUERY SKblCsMz user:1
-func (c cmdable) Query(ctx context.Context, collection, bucket, terms string, limit, offset int, lang string) *Cmd {
+func (c cmdable) Query(ctx context.Context, collection, bucket, terms string, limit, offset int, lang Lang) *QueryCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdSearchQuery
 	qb.Collection = collection
@@ -77,16 +138,20 @@ func (c cmdable) Query(ctx context.Context, collection, bucket, terms string, li
 	qb.Text = terms
 	qb.Limit = limit
 	qb.Offset = offset
-	qb.Lang = lang
+	qb.Lang = string(lang)
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := newQueryCmd(ctx, c, qb)
+	if err := lang.validate(); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
 	_ = c(ctx, cmd)
 	return cmd
 }
 
 // SUGGEST <collection> <bucket> "<word>" [LIMIT(<count>)]?
 // Return
-func (c cmdable) Suggest(ctx context.Context, collection, bucket, word string, limit int) *Cmd {
+func (c cmdable) Suggest(ctx context.Context, collection, bucket, word string, limit int) *SuggestCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdSearchSuggest
 	qb.Collection = collection
@@ -94,7 +159,23 @@ func (c cmdable) Suggest(ctx context.Context, collection, bucket, word string, l
 	qb.Text = word
 	qb.Limit = limit
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := NewSuggestCmd(ctx, qb.Encode()...)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// LIST <collection> <bucket> [LIMIT(<count>)]? [OFFSET(<count>)]?
+// Return PENDING marker
+// After EVENT LIST marker word1 word2
+func (c cmdable) List(ctx context.Context, collection, bucket string, limit, offset int) *ListCmd {
+	qb := NewQueryBuilder()
+	qb.Command = CmdSearchList
+	qb.Collection = collection
+	qb.Bucket = bucket
+	qb.Limit = limit
+	qb.Offset = offset
+
+	cmd := newListCmd(ctx, c, qb)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -102,28 +183,29 @@ func (c cmdable) Suggest(ctx context.Context, collection, bucket, word string, l
 //------------------------------------------------------------------------------
 // PUSH <collection> <bucket> <object> "<text>" [LANG(<locale>)]?
 // Return OK
-func (c ingestCmdable) Push(ctx context.Context, collection, bucket, object, text, lang string) *Cmd {
+// Text too long for the connection buffer is sent as one PUSH per chunk.
+func (c ingestCmdable) Push(ctx context.Context, collection, bucket, object, text string, lang Lang) *PushCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdIngestPush
 	qb.Collection = collection
 	qb.Bucket = bucket
 	qb.Object = object
 	qb.Text = text
-	qb.Lang = lang
+	qb.Lang = string(lang)
 
-	cmd := NewCmd(ctx, qb.Encode()...)
-	_ = c(ctx, cmd)
-	return cmd
-}
-
-func (c ingestCmdable) MPush(ctx context.Context, items []IngestItem) *Cmd {
-	cmd := NewCmd(ctx, "NOT_IMPLEMENTED")
+	cmd := newPushCmd(ctx, qb)
+	if err := lang.validate(); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
 	_ = c(ctx, cmd)
 	return cmd
 }
 
 // POP <collection> <bucket> <object> "<text>"
-func (c ingestCmdable) Pop(ctx context.Context, collection, bucket, object, text string) *Cmd {
+// Return RESULT 2
+// Text too long for the connection buffer is sent as one POP per chunk.
+func (c ingestCmdable) Pop(ctx context.Context, collection, bucket, object, text string) *PopCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdIngestPop
 	qb.Collection = collection
@@ -131,13 +213,7 @@ func (c ingestCmdable) Pop(ctx context.Context, collection, bucket, object, text
 	qb.Object = object
 	qb.Text = text
 
-	cmd := NewCmd(ctx, qb.Encode()...)
-	_ = c(ctx, cmd)
-	return cmd
-}
-
-func (c ingestCmdable) MPop(ctx context.Context, items []IngestItem) *Cmd {
-	cmd := NewCmd(ctx, "NOT_IMPLEMENTED")
+	cmd := newPopCmd(ctx, qb)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -198,24 +274,24 @@ func (c ingestCmdable) FlushObject(ctx context.Context, collection, bucket, obje
 //------------------------------------------------------------------------------
 // TRIGGER [<action: consolidate, backup, restore>]? [<data: backup, restore>]?
 // Return OK
-func (c controlCmdable) Trigger(ctx context.Context, action, data string) *Cmd {
+func (c controlCmdable) Trigger(ctx context.Context, action, data string) *StatusCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdControlTrigger
 	qb.Action = action
 	qb.Data = data
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := NewStatusCmd(ctx, qb.Encode()...)
 	_ = c(ctx, cmd)
 	return cmd
 }
 
 // INFO
 // Return RESULT uptime(118725) clients_connected(2) commands_total(54) command_latency_best(1) command_latency_worst(25) kv_open_count(0) fst_open_count(0) fst_consolidate_count(0)
-func (c controlCmdable) Info(ctx context.Context) *Cmd {
+func (c controlCmdable) Info(ctx context.Context) *InfoCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdControlInfo
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := NewInfoCmd(ctx, qb.Encode()...)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -223,8 +299,8 @@ func (c controlCmdable) Info(ctx context.Context) *Cmd {
 //------------------------------------------------------------------------------
 // START <mode: search,ingest> <password: channel.auth_password>
 // Return STARTED search protocol(1) buffer(20000)
-func (c statefulCmdable) Start(ctx context.Context, channelMode, authPassword string) *Cmd {
-	cmd := NewCmd(ctx, CmdSearchStart, channelMode, authPassword)
+func (c statefulCmdable) Start(ctx context.Context, channelMode, authPassword string) *StartCmd {
+	cmd := NewStartCmd(ctx, CmdSearchStart, channelMode, authPassword)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -232,22 +308,22 @@ func (c statefulCmdable) Start(ctx context.Context, channelMode, authPassword st
 //------------------------------------------------------------------------------
 // PING
 // Return PONG
-func (c baseCmdable) Ping(ctx context.Context) *Cmd {
+func (c baseCmdable) Ping(ctx context.Context) *StatusCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdPing
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := NewStatusCmd(ctx, qb.Encode()...)
 	_ = c(ctx, cmd)
 	return cmd
 }
 
 // QUIT
 // Return ENDED quit
-func (c baseCmdable) Quit(ctx context.Context) *Cmd {
+func (c baseCmdable) Quit(ctx context.Context) *StatusCmd {
 	qb := NewQueryBuilder()
 	qb.Command = CmdQuit
 
-	cmd := NewCmd(ctx, qb.Encode()...)
+	cmd := NewStatusCmd(ctx, qb.Encode()...)
 	_ = c(ctx, cmd)
 	return cmd
 }
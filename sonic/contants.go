@@ -19,6 +19,7 @@ const (
 	// Search Mode: ChannelSearch
 	CmdSearchQuery   = "QUERY"
 	CmdSearchSuggest = "SUGGEST"
+	CmdSearchList    = "LIST"
 	CmdSearchPing    = "PING" // PING
 
 	// Ingest Mode: ChannelIngest
@@ -41,6 +42,12 @@ const (
 	TriggerActionRestore     = "restore"
 )
 
+// Number of QUERY results returned by Sonic when LIMIT is not sent
+const DefaultQueryLimit = 10
+
+// Number of LIST words returned by Sonic when LIMIT is not sent
+const DefaultListLimit = 100
+
 // Trigger Data
 const (
 	TriggerDataBackup  = "backup"
@@ -49,94 +56,94 @@ const (
 
 // Sonic Supported Language: an ISO 639-3 locale code
 const (
-	LangAutoDetect = ""
-	LangNone       = "none"
-	LangAfr        = "afr"
-	LangAka        = "aka"
-	LangAmh        = "amh"
-	LangAra        = "ara"
-	LangAzj        = "azj"
-	LangBel        = "bel"
-	LangBen        = "ben"
-	LangBho        = "bho"
-	LangBul        = "bul"
-	LangCat        = "cat"
-	LangCeb        = "ceb"
-	LangCes        = "ces"
-	LangCmn        = "cmn"
-	LangDan        = "dan"
-	LangDeu        = "deu"
-	LangEll        = "ell"
-	LangEng        = "eng"
-	LangEpo        = "epo"
-	LangEst        = "est"
-	LangFin        = "fin"
-	LangFra        = "fra"
-	LangGuj        = "guj"
-	LangHat        = "hat"
-	LangHau        = "hau"
-	LangHeb        = "heb"
-	LangHin        = "hin"
-	LangHrv        = "hrv"
-	LangHun        = "hun"
-	LangIbo        = "ibo"
-	LangIlo        = "ilo"
-	LangInd        = "ind"
-	LangIta        = "ita"
-	LangJav        = "jav"
-	LangJpn        = "jpn"
-	LangKan        = "kan"
-	LangKat        = "kat"
-	LangKhm        = "khm"
-	LangKin        = "kin"
-	LangKor        = "kor"
-	LangKur        = "kur"
-	LangLat        = "lat"
-	LangLav        = "lav"
-	LangLit        = "lit"
-	LangMai        = "mai"
-	LangMal        = "mal"
-	LangMar        = "mar"
-	LangMkd        = "mkd"
-	LangMlg        = "mlg"
-	LangMod        = "mod"
-	LangMya        = "mya"
-	LangNep        = "nep"
-	LangNld        = "nld"
-	LangNno        = "nno"
-	LangNob        = "nob"
-	LangNya        = "nya"
-	LangOri        = "ori"
-	LangOrm        = "orm"
-	LangPan        = "pan"
-	LangPes        = "pes"
-	LangPol        = "pol"
-	LangPor        = "por"
-	LangRon        = "ron"
-	LangRun        = "run"
-	LangRus        = "rus"
-	LangSin        = "sin"
-	LangSkr        = "skr"
-	LangSlk        = "slk"
-	LangSlv        = "slv"
-	LangSna        = "sna"
-	LangSom        = "som"
-	LangSpa        = "spa"
-	LangSrp        = "srp"
-	LangSwe        = "swe"
-	LangTam        = "tam"
-	LangTel        = "tel"
-	LangTgl        = "tgl"
-	LangTha        = "tha"
-	LangTir        = "tir"
-	LangTuk        = "tuk"
-	LangTur        = "tur"
-	LangUig        = "uig"
-	LangUkr        = "ukr"
-	LangUrd        = "urd"
-	LangUzb        = "uzb"
-	LangVie        = "vie"
-	LangYdd        = "ydd"
-	LangYor        = "yor"
-	LangZul        = "zul"
+	LangAutoDetect Lang = ""
+	LangNone       Lang = "none"
+	LangAfr        Lang = "afr"
+	LangAka        Lang = "aka"
+	LangAmh        Lang = "amh"
+	LangAra        Lang = "ara"
+	LangAzj        Lang = "azj"
+	LangBel        Lang = "bel"
+	LangBen        Lang = "ben"
+	LangBho        Lang = "bho"
+	LangBul        Lang = "bul"
+	LangCat        Lang = "cat"
+	LangCeb        Lang = "ceb"
+	LangCes        Lang = "ces"
+	LangCmn        Lang = "cmn"
+	LangDan        Lang = "dan"
+	LangDeu        Lang = "deu"
+	LangEll        Lang = "ell"
+	LangEng        Lang = "eng"
+	LangEpo        Lang = "epo"
+	LangEst        Lang = "est"
+	LangFin        Lang = "fin"
+	LangFra        Lang = "fra"
+	LangGuj        Lang = "guj"
+	LangHat        Lang = "hat"
+	LangHau        Lang = "hau"
+	LangHeb        Lang = "heb"
+	LangHin        Lang = "hin"
+	LangHrv        Lang = "hrv"
+	LangHun        Lang = "hun"
+	LangIbo        Lang = "ibo"
+	LangIlo        Lang = "ilo"
+	LangInd        Lang = "ind"
+	LangIta        Lang = "ita"
+	LangJav        Lang = "jav"
+	LangJpn        Lang = "jpn"
+	LangKan        Lang = "kan"
+	LangKat        Lang = "kat"
+	LangKhm        Lang = "khm"
+	LangKin        Lang = "kin"
+	LangKor        Lang = "kor"
+	LangKur        Lang = "kur"
+	LangLat        Lang = "lat"
+	LangLav        Lang = "lav"
+	LangLit        Lang = "lit"
+	LangMai        Lang = "mai"
+	LangMal        Lang = "mal"
+	LangMar        Lang = "mar"
+	LangMkd        Lang = "mkd"
+	LangMlg        Lang = "mlg"
+	LangMod        Lang = "mod"
+	LangMya        Lang = "mya"
+	LangNep        Lang = "nep"
+	LangNld        Lang = "nld"
+	LangNno        Lang = "nno"
+	LangNob        Lang = "nob"
+	LangNya        Lang = "nya"
+	LangOri        Lang = "ori"
+	LangOrm        Lang = "orm"
+	LangPan        Lang = "pan"
+	LangPes        Lang = "pes"
+	LangPol        Lang = "pol"
+	LangPor        Lang = "por"
+	LangRon        Lang = "ron"
+	LangRun        Lang = "run"
+	LangRus        Lang = "rus"
+	LangSin        Lang = "sin"
+	LangSkr        Lang = "skr"
+	LangSlk        Lang = "slk"
+	LangSlv        Lang = "slv"
+	LangSna        Lang = "sna"
+	LangSom        Lang = "som"
+	LangSpa        Lang = "spa"
+	LangSrp        Lang = "srp"
+	LangSwe        Lang = "swe"
+	LangTam        Lang = "tam"
+	LangTel        Lang = "tel"
+	LangTgl        Lang = "tgl"
+	LangTha        Lang = "tha"
+	LangTir        Lang = "tir"
+	LangTuk        Lang = "tuk"
+	LangTur        Lang = "tur"
+	LangUig        Lang = "uig"
+	LangUkr        Lang = "ukr"
+	LangUrd        Lang = "urd"
+	LangUzb        Lang = "uzb"
+	LangVie        Lang = "vie"
+	LangYdd        Lang = "ydd"
+	LangYor        Lang = "yor"
+	LangZul        Lang = "zul"
 )
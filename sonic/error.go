@@ -2,6 +2,8 @@ package sonic
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"strings"
@@ -13,6 +15,41 @@ import (
 // ErrClosed performs any operation on the closed client will return this error.
 var ErrClosed = pool.ErrClosed
 
+// ErrWrongChannel is matched by errors of commands sent on a client whose
+// channel mode can't run them, e.g. QUERY on an ingest client.
+var ErrWrongChannel = errors.New("sonic: wrong channel")
+
+// ChannelError reports a command that was not sent because the channel
+// mode of the client can't run it.
+type ChannelError struct {
+	Command  string
+	Channel  string
+	Required []string
+}
+
+func (e *ChannelError) Error() string {
+	return fmt.Sprintf("sonic: %s requires the %s channel, client uses %s",
+		e.Command, strings.Join(e.Required, " or "), e.Channel)
+}
+
+func (e *ChannelError) Unwrap() error {
+	return ErrWrongChannel
+}
+
+// Kinds of errors replied by the Sonic server. Match them with errors.Is;
+// use errors.As with a proto.SonicError to get the code and detail. Unknown
+// codes match ErrServer.
+var (
+	ErrInvalidFormat  = proto.ErrInvalidFormat
+	ErrUnknownCommand = proto.ErrUnknownCommand
+	ErrNotFound       = proto.ErrNotFound
+	ErrQueryTooLong   = proto.ErrQueryTooLong
+	ErrQuery          = proto.ErrQuery
+	ErrPolicyReject   = proto.ErrPolicyReject
+	ErrAuthFailed     = proto.ErrAuthFailed
+	ErrServer         = proto.ErrServer
+)
+
 type Error interface {
 	error
 
@@ -33,6 +70,12 @@ func shouldRetry(err error, retryTimeout bool) bool {
 		return false
 	}
 
+	// Only retry server errors caused by the server state, bad commands
+	// fail the same way every time.
+	if v, ok := err.(proto.SonicError); ok {
+		return v.Temporary()
+	}
+
 	if v, ok := err.(timeoutError); ok {
 		if v.Timeout() {
 			return retryTimeout
@@ -40,14 +83,6 @@ func shouldRetry(err error, retryTimeout bool) bool {
 		return true
 	}
 
-	s := err.Error()
-	if s == "ERR max number of clients reached" {
-		return true
-	}
-	if strings.HasPrefix(s, "ERR ") {
-		return true
-	}
-
 	return false
 }
 
@@ -0,0 +1,119 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandChannels(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"PING", []string{ChannelSearch, ChannelIngest, ChannelControl}},
+		{"quit", []string{ChannelSearch, ChannelIngest, ChannelControl}},
+		{"START", []string{ChannelUninitialized}},
+		{"QUERY", []string{ChannelSearch}},
+		{"suggest", []string{ChannelSearch}},
+		{"LIST", []string{ChannelSearch}},
+		{"PUSH", []string{ChannelIngest}},
+		{"POP", []string{ChannelIngest}},
+		{"COUNT", []string{ChannelIngest}},
+		{"FLUSHC", []string{ChannelIngest}},
+		{"FLUSHB", []string{ChannelIngest}},
+		{"FLUSHO", []string{ChannelIngest}},
+		{"TRIGGER", []string{ChannelControl}},
+		{"INFO", []string{ChannelControl}},
+		{"UNKNOWN", nil},
+	}
+	for _, tt := range tests {
+		got := CommandChannels(tt.name)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommandChannels(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if len(got) > 0 {
+			// The result is a copy.
+			got[0] = "changed"
+			if CommandChannels(tt.name)[0] == "changed" {
+				t.Fatalf("CommandChannels(%q) returned the shared slice", tt.name)
+			}
+		}
+	}
+}
+
+func TestWrongChannel(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+
+	tests := []struct {
+		channel string
+		args    []string
+	}{
+		{ChannelSearch, []string{"PUSH", "c", "b", "o", `"text"`}},
+		{ChannelSearch, []string{"POP", "c", "b", "o", `"text"`}},
+		{ChannelSearch, []string{"COUNT", "c", "b"}},
+		{ChannelSearch, []string{"FLUSHC", "c"}},
+		{ChannelSearch, []string{"FLUSHB", "c", "b"}},
+		{ChannelSearch, []string{"FLUSHO", "c", "b", "o"}},
+		{ChannelSearch, []string{"TRIGGER", "consolidate"}},
+		{ChannelSearch, []string{"INFO"}},
+		{ChannelIngest, []string{"QUERY", "c", "b", `"text"`}},
+		{ChannelIngest, []string{"SUGGEST", "c", "b", `"te"`}},
+		{ChannelIngest, []string{"list", "c", "b"}},
+		{ChannelIngest, []string{"TRIGGER", "consolidate"}},
+		{ChannelIngest, []string{"START", "ingest"}},
+		{ChannelControl, []string{"QUERY", "c", "b", `"text"`}},
+		{ChannelControl, []string{"PUSH", "c", "b", "o", `"text"`}},
+		{ChannelControl, []string{"COUNT", "c", "b"}},
+	}
+
+	check := func(t *testing.T, channel string, args []string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrWrongChannel) {
+			t.Fatalf("got %v, want %v", err, ErrWrongChannel)
+		}
+		var cerr *ChannelError
+		if !errors.As(err, &cerr) {
+			t.Fatalf("got %T, want a *ChannelError", err)
+		}
+		want := &ChannelError{
+			Command:  strings.ToUpper(args[0]),
+			Channel:  channel,
+			Required: CommandChannels(args[0]),
+		}
+		if !reflect.DeepEqual(cerr, want) {
+			t.Fatalf("got %+v, want %+v", cerr, want)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.channel, tt.args[0]), func(t *testing.T) {
+			client := newTestClient(t, srv.Addr, tt.channel)
+
+			t.Run("Client", func(t *testing.T) {
+				cmd := NewCmd(ctx, tt.args...)
+				err := client.Process(ctx, cmd)
+				check(t, tt.channel, tt.args, err)
+				if cmd.Err() != err {
+					t.Fatalf("cmd.Err() = %v, want %v", cmd.Err(), err)
+				}
+			})
+			t.Run("Conn", func(t *testing.T) {
+				conn := client.Conn(ctx)
+				defer conn.Close()
+				check(t, tt.channel, tt.args, conn.Process(ctx, NewCmd(ctx, tt.args...)))
+			})
+			t.Run("Pipeline", func(t *testing.T) {
+				cmds, err := client.Pipelined(ctx, func(pipe Pipeliner) error {
+					return pipe.Process(ctx, NewCmd(ctx, tt.args...))
+				})
+				check(t, tt.channel, tt.args, err)
+				check(t, tt.channel, tt.args, cmds[0].Err())
+			})
+		})
+	}
+}
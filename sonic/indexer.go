@@ -0,0 +1,94 @@
+package sonic
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// IndexDiff holds the words changed by Indexer.Update, in text order.
+type IndexDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// Indexer updates the text of indexed objects in place.
+type Indexer struct {
+	c    IngestCmdable
+	lang Lang
+}
+
+// NewIndexer returns an Indexer pushing words through c in lang. Only the
+// changed words are pushed, so lang should be set rather than left to
+// detection.
+func NewIndexer(c IngestCmdable, lang Lang) *Indexer {
+	return &Indexer{
+		c:    c,
+		lang: lang,
+	}
+}
+
+// Update replaces the oldText of an object with newText without flushing
+// it: the words only found in newText are pushed first, then the words only
+// found in oldText are popped, so the object is always found by its
+// unchanged words. Both commands are split to fit the connection buffer.
+func (ix *Indexer) Update(ctx context.Context, collection, bucket, object, oldText, newText string) (*IndexDiff, error) {
+	oldWords := indexWords(oldText)
+	newWords := indexWords(newText)
+
+	diff := &IndexDiff{
+		Added:   wordsNotIn(newWords, oldWords),
+		Removed: wordsNotIn(oldWords, newWords),
+	}
+
+	if len(diff.Added) > 0 {
+		err := ix.c.Push(ctx, collection, bucket, object, strings.Join(diff.Added, " "), ix.lang).Err()
+		if err != nil {
+			return diff, err
+		}
+	}
+	if len(diff.Removed) > 0 {
+		err := ix.c.Pop(ctx, collection, bucket, object, strings.Join(diff.Removed, " ")).Err()
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	return diff, nil
+}
+
+// indexWords returns the distinct lowercased words of text, made of letters
+// and digits, in text order.
+func indexWords(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(fields))
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		word := strings.ToLower(field)
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		words = append(words, word)
+	}
+	return words
+}
+
+// wordsNotIn returns the words of a missing from b.
+func wordsNotIn(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, word := range b {
+		set[word] = struct{}{}
+	}
+
+	var words []string
+	for _, word := range a {
+		if _, ok := set[word]; !ok {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,98 @@
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+func TestIndexerUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		added   []string
+		removed []string
+	}{
+		{"unchanged", "Hello world", "hello, WORLD!", nil, nil},
+		{"added", "hello", "hello brave new world", []string{"brave", "new", "world"}, nil},
+		{"removed", "hello brave new world", "hello world", nil, []string{"brave", "new"}},
+		{"replaced", "the quick fox", "the slow fox", []string{"slow"}, []string{"quick"}},
+		{"duplicates", "a a b", "b c c", []string{"c"}, []string{"a"}},
+		{"from empty", "", "first text", []string{"first", "text"}, nil},
+		{"to empty", "last text", "", nil, []string{"last", "text"}},
+	}
+
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	ix := NewIndexer(ingest, LangNone)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.Reset()
+			if tt.oldText != "" {
+				if err := ingest.Push(ctx, "c", "b", "o", tt.oldText, LangNone).Err(); err != nil {
+					t.Fatalf("Push: %v", err)
+				}
+			}
+
+			diff, err := ix.Update(ctx, "c", "b", "o", tt.oldText, tt.newText)
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if !reflect.DeepEqual(diff.Added, tt.added) || !reflect.DeepEqual(diff.Removed, tt.removed) {
+				t.Fatalf("diff = %+v, want added %q and removed %q", diff, tt.added, tt.removed)
+			}
+
+			want := indexWords(tt.newText)
+			sort.Strings(want)
+			if got := srv.Words("c", "b", "o"); len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+				t.Fatalf("words = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestIndexerUpdateCommands(t *testing.T) {
+	ctx := context.Background()
+
+	// New words are pushed before old words are popped.
+	steps, err := sonictest.ParseTranscript(strings.NewReader(`
+< CONNECTED <sonic-server v1.4.0>
+> START ingest SecretPassword
+< STARTED ingest protocol(1) buffer(20000)
+> PUSH c b o "slow brown" LANG(eng)
+< OK
+> POP c b o "quick"
+< RESULT 1
+`))
+	if err != nil {
+		t.Fatalf("ParseTranscript: %v", err)
+	}
+	mock := sonictest.NewMockServer(steps)
+	defer mock.Close()
+
+	ingest := NewClient(&Options{
+		Addr:         mock.Addr,
+		AuthPassword: "SecretPassword",
+		ChannelMode:  ChannelIngest,
+		PoolSize:     1,
+		MaxRetries:   -1,
+	})
+	defer ingest.Close()
+
+	ix := NewIndexer(ingest, LangEng)
+	if _, err := ix.Update(ctx, "c", "b", "o", "the quick fox", "the slow brown fox"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
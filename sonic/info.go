@@ -0,0 +1,69 @@
+package sonic
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ServerInfo holds the statistics returned by INFO, e.g.
+// RESULT uptime(118725) clients_connected(2) commands_total(54) command_latency_best(1) command_latency_worst(25) kv_open_count(0) fst_open_count(0) fst_consolidate_count(0)
+type ServerInfo struct {
+	Uptime           time.Duration
+	ClientsConnected int64
+	CommandsTotal    int64
+
+	CommandLatencyBest  time.Duration
+	CommandLatencyWorst time.Duration
+
+	KVOpenCount         int64
+	FSTOpenCount        int64
+	FSTConsolidateCount int64
+
+	// Fields this client does not know about yet, by name.
+	Extra map[string]string
+}
+
+func parseServerInfo(values map[string]string) (*ServerInfo, error) {
+	info := &ServerInfo{}
+
+	for name, value := range values {
+		var err error
+		switch name {
+		case "uptime":
+			info.Uptime, err = parseDuration(value, time.Second)
+		case "clients_connected":
+			info.ClientsConnected, err = strconv.ParseInt(value, 10, 64)
+		case "commands_total":
+			info.CommandsTotal, err = strconv.ParseInt(value, 10, 64)
+		case "command_latency_best":
+			info.CommandLatencyBest, err = parseDuration(value, time.Millisecond)
+		case "command_latency_worst":
+			info.CommandLatencyWorst, err = parseDuration(value, time.Millisecond)
+		case "kv_open_count":
+			info.KVOpenCount, err = strconv.ParseInt(value, 10, 64)
+		case "fst_open_count":
+			info.FSTOpenCount, err = strconv.ParseInt(value, 10, 64)
+		case "fst_consolidate_count":
+			info.FSTConsolidateCount, err = strconv.ParseInt(value, 10, 64)
+		default:
+			if info.Extra == nil {
+				info.Extra = make(map[string]string)
+			}
+			info.Extra[name] = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sonic: can't parse info %s(%s): %w", name, value, err)
+		}
+	}
+
+	return info, nil
+}
+
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,126 @@
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+// infoReply runs INFO against a MockServer answering reply.
+func infoReply(t *testing.T, reply string) (*ServerInfo, error) {
+	t.Helper()
+	steps, err := sonictest.ParseTranscript(strings.NewReader(`
+< CONNECTED <sonic-server v1.4.0>
+> START control SecretPassword
+< STARTED control protocol(1) buffer(20000)
+> INFO
+< ` + reply + "\n"))
+	if err != nil {
+		t.Fatalf("ParseTranscript: %v", err)
+	}
+	mock := sonictest.NewMockServer(steps)
+
+	client := NewClient(&Options{
+		Addr:         mock.Addr,
+		AuthPassword: "SecretPassword",
+		ChannelMode:  ChannelControl,
+		PoolSize:     1,
+		MaxRetries:   -1,
+	})
+
+	info, err := client.Info(context.Background()).Result()
+	_ = client.Close()
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatalf("mock: %v", err)
+	}
+	return info, err
+}
+
+func TestInfoCmd(t *testing.T) {
+	info, err := infoReply(t, "RESULT uptime(118725) clients_connected(2) commands_total(54) command_latency_best(1) command_latency_worst(25) kv_open_count(3) fst_open_count(4) fst_consolidate_count(5) fst_pending_count(6)")
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+
+	want := &ServerInfo{
+		Uptime:              118725 * time.Second,
+		ClientsConnected:    2,
+		CommandsTotal:       54,
+		CommandLatencyBest:  time.Millisecond,
+		CommandLatencyWorst: 25 * time.Millisecond,
+		KVOpenCount:         3,
+		FSTOpenCount:        4,
+		FSTConsolidateCount: 5,
+		Extra:               map[string]string{"fst_pending_count": "6"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Fatalf("Info = %+v, want %+v", info, want)
+	}
+}
+
+func TestInfoCmdMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr string
+	}{
+		{"uptime", "RESULT uptime(1h)", "uptime(1h)"},
+		{"latency", "RESULT command_latency_best(-)", "command_latency_best(-)"},
+		{"count", "RESULT clients_connected(two)", "clients_connected(two)"},
+		{"empty value", "RESULT kv_open_count()", "kv_open_count()"},
+		{"overflow", "RESULT commands_total(99999999999999999999)", "commands_total(99999999999999999999)"},
+		{"error reply", "ERR unknown_command", "unknown_command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := infoReply(t, tt.reply)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Info = %+v, %v, want error %q", info, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseServerInfo(t *testing.T) {
+	info, err := parseServerInfo(map[string]string{})
+	if err != nil {
+		t.Fatalf("parseServerInfo: %v", err)
+	}
+	if !reflect.DeepEqual(info, &ServerInfo{}) {
+		t.Fatalf("parseServerInfo = %+v, want zero fields and no Extra", info)
+	}
+
+	// Unknown values are kept as is, even if they don't parse.
+	info, err = parseServerInfo(map[string]string{"uptime": "0", "version": "1.4.0", "mode": "?"})
+	if err != nil {
+		t.Fatalf("parseServerInfo: %v", err)
+	}
+	if want := map[string]string{"version": "1.4.0", "mode": "?"}; !reflect.DeepEqual(info.Extra, want) {
+		t.Fatalf("Extra = %v, want %v", info.Extra, want)
+	}
+}
+
+func TestInfoSonictest(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	control := newTestClient(t, srv.Addr, ChannelControl)
+
+	if err := ingest.Push(ctx, "messages", "default", "msg:1", "hello", LangEng).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	info, err := control.Info(ctx).Result()
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.ClientsConnected != 2 || info.CommandsTotal < 1 || info.KVOpenCount != 1 || info.Extra != nil {
+		t.Fatalf("Info = %+v", info)
+	}
+}
@@ -0,0 +1,164 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ListIterator is used to incrementally iterate over the words of a bucket.
+type ListIterator struct {
+	mu  sync.Mutex // protects cmd and pos
+	cmd *ListCmd
+	pos int
+}
+
+// Err returns the last iterator error, if any.
+func (it *ListIterator) Err() error {
+	it.mu.Lock()
+	err := it.cmd.Err()
+	it.mu.Unlock()
+	return err
+}
+
+// Next advances the cursor and returns true if more values can be read.
+func (it *ListIterator) Next(ctx context.Context) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	// Instantly return on errors.
+	if it.cmd.Err() != nil {
+		return false
+	}
+
+	for {
+		// Advance cursor, check if we are still within range.
+		if it.pos < len(it.cmd.val) {
+			it.pos++
+			return true
+		}
+
+		// Without a LIMIT Sonic returns pages of DefaultListLimit words;
+		// a short page is the last one.
+		limit := it.cmd.qb.Limit
+		if limit <= 0 {
+			limit = DefaultListLimit
+		}
+		if len(it.cmd.val) < limit {
+			return false
+		}
+
+		// Fetch next page.
+		qb := it.cmd.qb
+		qb.Limit = limit
+		qb.Offset += limit
+
+		it.cmd = newListCmd(ctx, it.cmd.process, qb)
+		it.pos = 0
+
+		_ = it.cmd.process(ctx, it.cmd)
+		if it.cmd.Err() != nil {
+			return false
+		}
+	}
+}
+
+// Val returns the word at the current cursor position.
+func (it *ListIterator) Val() string {
+	var v string
+
+	it.mu.Lock()
+	if it.cmd.Err() == nil && it.pos > 0 && it.pos <= len(it.cmd.val) {
+		v = it.cmd.val[it.pos-1]
+	}
+	it.mu.Unlock()
+
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+// QueryIterator is used to incrementally iterate over the results of a
+// query. Results that move to a following page while iterating, because the
+// index changed, are only returned once.
+type QueryIterator struct {
+	mu   sync.Mutex // protects every field
+	cmd  *QueryCmd
+	pos  int
+	max  int
+	n    int // results returned so far
+	seen map[string]struct{}
+}
+
+// Err returns the last iterator error, if any.
+func (it *QueryIterator) Err() error {
+	it.mu.Lock()
+	err := it.cmd.Err()
+	it.mu.Unlock()
+	return err
+}
+
+// Next advances the cursor and returns true if more values can be read.
+func (it *QueryIterator) Next(ctx context.Context) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	// Instantly return on errors or once the cap is reached.
+	if it.cmd.Err() != nil || (it.max > 0 && it.n >= it.max) {
+		return false
+	}
+
+	for {
+		// Advance cursor, skipping results already returned.
+		for it.pos < len(it.cmd.val) {
+			id := it.cmd.val[it.pos]
+			it.pos++
+			if _, ok := it.seen[id]; ok {
+				continue
+			}
+			it.seen[id] = struct{}{}
+			it.n++
+			return true
+		}
+
+		// Without a LIMIT Sonic returns pages of DefaultQueryLimit results;
+		// a short page is the last one.
+		limit := it.cmd.qb.Limit
+		if limit <= 0 {
+			limit = DefaultQueryLimit
+		}
+		if len(it.cmd.val) < limit {
+			return false
+		}
+		if it.cmd.process == nil {
+			it.cmd.SetErr(errors.New("sonic: QueryIterator requires a QueryCmd made by Query"))
+			return false
+		}
+
+		// Fetch next page.
+		qb := it.cmd.qb
+		qb.Limit = limit
+		qb.Offset += limit
+
+		it.cmd = newQueryCmd(ctx, it.cmd.process, qb)
+		it.pos = 0
+
+		_ = it.cmd.process(ctx, it.cmd)
+		if it.cmd.Err() != nil {
+			return false
+		}
+	}
+}
+
+// Val returns the object identifier at the current cursor position.
+func (it *QueryIterator) Val() string {
+	var v string
+
+	it.mu.Lock()
+	if it.cmd.Err() == nil && it.pos > 0 && it.pos <= len(it.cmd.val) {
+		v = it.cmd.val[it.pos-1]
+	}
+	it.mu.Unlock()
+
+	return v
+}
@@ -0,0 +1,132 @@
+package sonic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func pushObjects(t *testing.T, ingest *Client, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		err := ingest.Push(context.Background(), "fruits", "default", fmt.Sprintf("obj:%d", i), "apple", LangEng).Err()
+		if err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+}
+
+func TestQueryIteratorPages(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+	pushObjects(t, ingest, 25)
+
+	tests := []struct {
+		limit, max int
+		want       int
+	}{
+		{limit: 0, want: 25},
+		{limit: 5, want: 25},
+		{limit: 10, want: 25},
+		{limit: 25, want: 25},
+		{limit: 100, want: 25},
+		{limit: 5, max: 7, want: 7},
+		{limit: 0, max: 12, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit=%d/max=%d", tt.limit, tt.max), func(t *testing.T) {
+			it := search.Query(ctx, "fruits", "default", "apple", tt.limit, 0, LangEng).Iterator(tt.max)
+
+			seen := make(map[string]bool)
+			for it.Next(ctx) {
+				if seen[it.Val()] {
+					t.Fatalf("%s returned twice", it.Val())
+				}
+				seen[it.Val()] = true
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("Err: %v", err)
+			}
+			if len(seen) != tt.want {
+				t.Fatalf("got %d results, want %d", len(seen), tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryIteratorIndexChanges(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+	pushObjects(t, ingest, 9)
+
+	it := search.Query(ctx, "fruits", "default", "apple", 3, 0, LangEng).Iterator(0)
+	seen := make(map[string]bool)
+	for it.Next(ctx) {
+		if seen[it.Val()] {
+			t.Fatalf("%s returned twice", it.Val())
+		}
+		seen[it.Val()] = true
+
+		// Newest objects come first, so every push moves the objects not
+		// yet returned one page further.
+		if len(seen) == 2 {
+			if err := ingest.Push(ctx, "fruits", "default", "obj:new", "apple", LangEng).Err(); err != nil {
+				t.Fatalf("Push: %v", err)
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if len(seen) != 9 {
+		t.Fatalf("got %d results, want the 9 objects pushed before iterating", len(seen))
+	}
+}
+
+func TestListIteratorPages(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	// More words than the default LIST page holds.
+	const n = DefaultListLimit + 50
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	if err := ingest.Push(ctx, "fruits", "default", "obj:1", strings.Join(words, " "), LangNone).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// A single LIST without LIMIT is capped at the default page.
+	if got, err := search.List(ctx, "fruits", "default", 0, 0).Result(); err != nil || len(got) != DefaultListLimit {
+		t.Fatalf("List = %d words, %v, want %d", len(got), err, DefaultListLimit)
+	}
+
+	for _, limit := range []int{0, 7, 50, n, 500} {
+		t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+			it := search.List(ctx, "fruits", "default", limit, 0).Iterator()
+
+			seen := make(map[string]bool)
+			for it.Next(ctx) {
+				if seen[it.Val()] {
+					t.Fatalf("%s returned twice", it.Val())
+				}
+				seen[it.Val()] = true
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("Err: %v", err)
+			}
+			if len(seen) != n {
+				t.Fatalf("got %d words, want %d", len(seen), n)
+			}
+		})
+	}
+}
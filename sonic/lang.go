@@ -0,0 +1,264 @@
+package sonic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownLang is matched by errors of commands and ParseLang calls given
+// a language Sonic doesn't support.
+var ErrUnknownLang = errors.New("sonic: unknown lang")
+
+// Lang is an ISO 639-3 locale code supported by Sonic, LangNone or
+// LangAutoDetect. See ParseLang to get one from a BCP-47 tag.
+type Lang string
+
+type langInfo struct {
+	iso6391 string // empty if the language has no ISO 639-1 code
+	name    string
+}
+
+// Languages supported by Sonic.
+var langs = map[Lang]langInfo{
+	LangAfr: {"af", "Afrikaans"},
+	LangAka: {"ak", "Akan"},
+	LangAmh: {"am", "Amharic"},
+	LangAra: {"ar", "Arabic"},
+	LangAzj: {"az", "Azerbaijani"},
+	LangBel: {"be", "Belarusian"},
+	LangBen: {"bn", "Bengali"},
+	LangBho: {"", "Bhojpuri"},
+	LangBul: {"bg", "Bulgarian"},
+	LangCat: {"ca", "Catalan"},
+	LangCeb: {"", "Cebuano"},
+	LangCes: {"cs", "Czech"},
+	LangCmn: {"zh", "Mandarin"},
+	LangDan: {"da", "Danish"},
+	LangDeu: {"de", "German"},
+	LangEll: {"el", "Greek"},
+	LangEng: {"en", "English"},
+	LangEpo: {"eo", "Esperanto"},
+	LangEst: {"et", "Estonian"},
+	LangFin: {"fi", "Finnish"},
+	LangFra: {"fr", "French"},
+	LangGuj: {"gu", "Gujarati"},
+	LangHat: {"ht", "Haitian Creole"},
+	LangHau: {"ha", "Hausa"},
+	LangHeb: {"he", "Hebrew"},
+	LangHin: {"hi", "Hindi"},
+	LangHrv: {"hr", "Croatian"},
+	LangHun: {"hu", "Hungarian"},
+	LangIbo: {"ig", "Igbo"},
+	LangIlo: {"", "Ilocano"},
+	LangInd: {"id", "Indonesian"},
+	LangIta: {"it", "Italian"},
+	LangJav: {"jv", "Javanese"},
+	LangJpn: {"ja", "Japanese"},
+	LangKan: {"kn", "Kannada"},
+	LangKat: {"ka", "Georgian"},
+	LangKhm: {"km", "Khmer"},
+	LangKin: {"rw", "Kinyarwanda"},
+	LangKor: {"ko", "Korean"},
+	LangKur: {"ku", "Kurdish"},
+	LangLat: {"la", "Latin"},
+	LangLav: {"lv", "Latvian"},
+	LangLit: {"lt", "Lithuanian"},
+	LangMai: {"", "Maithili"},
+	LangMal: {"ml", "Malayalam"},
+	LangMar: {"mr", "Marathi"},
+	LangMkd: {"mk", "Macedonian"},
+	LangMlg: {"mg", "Malagasy"},
+	LangMod: {"", "Mobilian"},
+	LangMya: {"my", "Burmese"},
+	LangNep: {"ne", "Nepali"},
+	LangNld: {"nl", "Dutch"},
+	LangNno: {"nn", "Norwegian Nynorsk"},
+	LangNob: {"nb", "Norwegian Bokmal"},
+	LangNya: {"ny", "Chichewa"},
+	LangOri: {"or", "Oriya"},
+	LangOrm: {"om", "Oromo"},
+	LangPan: {"pa", "Punjabi"},
+	LangPes: {"fa", "Persian"},
+	LangPol: {"pl", "Polish"},
+	LangPor: {"pt", "Portuguese"},
+	LangRon: {"ro", "Romanian"},
+	LangRun: {"rn", "Rundi"},
+	LangRus: {"ru", "Russian"},
+	LangSin: {"si", "Sinhala"},
+	LangSkr: {"", "Saraiki"},
+	LangSlk: {"sk", "Slovak"},
+	LangSlv: {"sl", "Slovenian"},
+	LangSna: {"sn", "Shona"},
+	LangSom: {"so", "Somali"},
+	LangSpa: {"es", "Spanish"},
+	LangSrp: {"sr", "Serbian"},
+	LangSwe: {"sv", "Swedish"},
+	LangTam: {"ta", "Tamil"},
+	LangTel: {"te", "Telugu"},
+	LangTgl: {"tl", "Tagalog"},
+	LangTha: {"th", "Thai"},
+	LangTir: {"ti", "Tigrinya"},
+	LangTuk: {"tk", "Turkmen"},
+	LangTur: {"tr", "Turkish"},
+	LangUig: {"ug", "Uyghur"},
+	LangUkr: {"uk", "Ukrainian"},
+	LangUrd: {"ur", "Urdu"},
+	LangUzb: {"uz", "Uzbek"},
+	LangVie: {"vi", "Vietnamese"},
+	LangYdd: {"yi", "Yiddish"},
+	LangYor: {"yo", "Yoruba"},
+	LangZul: {"zu", "Zulu"},
+}
+
+// Other codes and names of the supported languages, lowercased.
+var langAliases = map[string]Lang{
+	"aze":       LangAzj,
+	"zho":       LangCmn,
+	"chi":       LangCmn,
+	"chinese":   LangCmn,
+	"fas":       LangPes,
+	"per":       LangPes,
+	"farsi":     LangPes,
+	"nor":       LangNob,
+	"no":        LangNob,
+	"norwegian": LangNob,
+	"fil":       LangTgl,
+	"filipino":  LangTgl,
+	"yid":       LangYdd,
+	"ger":       LangDeu,
+	"fre":       LangFra,
+	"dut":       LangNld,
+	"gre":       LangEll,
+	"cze":       LangCes,
+	"slo":       LangSlk,
+	"rum":       LangRon,
+	"moldavian": LangRon,
+	"odia":      LangOri,
+	"sinhalese": LangSin,
+	"panjabi":   LangPan,
+	"kirundi":   LangRun,
+	"nyanja":    LangNya,
+}
+
+// langLookup indexes the supported languages by code, ISO 639-1 code and
+// lowercased name.
+var langLookup = func() map[string]Lang {
+	m := make(map[string]Lang, 3*len(langs)+len(langAliases))
+	for lang, info := range langs {
+		m[string(lang)] = lang
+		if info.iso6391 != "" {
+			m[info.iso6391] = lang
+		}
+		m[strings.ToLower(info.name)] = lang
+	}
+	for alias, lang := range langAliases {
+		m[alias] = lang
+	}
+	return m
+}()
+
+// ParseLang returns the Lang of an ISO 639-3 or ISO 639-1 code, a BCP-47
+// tag like "pt-BR" or "zh-Hant-TW", or an English name like "Portuguese".
+// Matching is case-insensitive; "none" and "" return LangNone and
+// LangAutoDetect.
+func ParseLang(s string) (Lang, error) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	switch key {
+	case string(LangAutoDetect):
+		return LangAutoDetect, nil
+	case string(LangNone):
+		return LangNone, nil
+	}
+
+	if lang, ok := langLookup[key]; ok {
+		return lang, nil
+	}
+	// BCP-47 tags start with the language subtag, region, script and
+	// variant subtags don't change the stop words.
+	if i := strings.IndexAny(key, "-_"); i == 2 || i == 3 {
+		if lang, ok := langLookup[key[:i]]; ok {
+			return lang, nil
+		}
+	}
+
+	return LangAutoDetect, fmt.Errorf("%w: %q", ErrUnknownLang, s)
+}
+
+// Langs returns the languages supported by Sonic, sorted by code.
+func Langs() []Lang {
+	list := make([]Lang, 0, len(langs))
+	for lang := range langs {
+		list = append(list, lang)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// Valid reports whether l is supported by Sonic, LangNone or LangAutoDetect.
+func (l Lang) Valid() bool {
+	if l == LangAutoDetect || l == LangNone {
+		return true
+	}
+	_, ok := langs[l]
+	return ok
+}
+
+// Name returns the English name of l, or an empty string if l is not
+// supported.
+func (l Lang) Name() string {
+	return langs[l].name
+}
+
+// ISO6391 returns the two letter code of l, or an empty string if it has
+// none.
+func (l Lang) ISO6391() string {
+	return langs[l].iso6391
+}
+
+func (l Lang) String() string {
+	return string(l)
+}
+
+// LangDetector detects the language of texts, see Options.LangDetector.
+type LangDetector interface {
+	// DetectLang returns the language of text, or LangAutoDetect if it
+	// can't be detected confidently.
+	DetectLang(text string) Lang
+}
+
+// detectLang sets the language of a PUSH or QUERY command sent with
+// LangAutoDetect.
+func detectLang(d LangDetector, cmd Cmder) {
+	switch cmd := cmd.(type) {
+	case *QueryCmd:
+		if detectQueryLang(d, &cmd.qb) {
+			cmd.args = cmd.qb.Encode()
+		}
+	case *PushCmd:
+		if detectQueryLang(d, &cmd.qb) {
+			cmd.args = cmd.qb.Encode()
+		}
+	}
+}
+
+func detectQueryLang(d LangDetector, qb *QueryBuilder) bool {
+	if qb.Command == "" || qb.Lang != string(LangAutoDetect) {
+		return false
+	}
+
+	lang := d.DetectLang(qb.Text)
+	if lang == LangAutoDetect || !lang.Valid() {
+		return false
+	}
+	qb.Lang = string(lang)
+	return true
+}
+
+func (l Lang) validate() error {
+	if !l.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownLang, string(l))
+	}
+	return nil
+}
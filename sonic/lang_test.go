@@ -0,0 +1,82 @@
+package sonic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLang(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Lang
+	}{
+		// ISO 639-3 and ISO 639-1 codes.
+		{"por", LangPor},
+		{"pt", LangPor},
+		{"cmn", LangCmn},
+		{"zh", LangCmn},
+		// BCP-47 tags.
+		{"pt-BR", LangPor},
+		{"pt_BR", LangPor},
+		{"zh-Hant-TW", LangCmn},
+		{"en-US", LangEng},
+		{"deu-CH", LangDeu},
+		// English names.
+		{"Portuguese", LangPor},
+		{"German", LangDeu},
+		{"Norwegian Bokmal", LangNob},
+		// Aliases.
+		{"zho", LangCmn},
+		{"ger", LangDeu},
+		{"no", LangNob},
+		// Case and surrounding spaces.
+		{"PT-br", LangPor},
+		{"ENG", LangEng},
+		{"  english ", LangEng},
+		// None and auto-detection.
+		{"none", LangNone},
+		{"NONE", LangNone},
+		{"", LangAutoDetect},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLang(tt.in)
+		if err != nil {
+			t.Errorf("ParseLang(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLang(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLangUnknown(t *testing.T) {
+	for _, in := range []string{"xx", "klingon", "xx-BR", "p", "portu", "-pt", "e n"} {
+		lang, err := ParseLang(in)
+		if !errors.Is(err, ErrUnknownLang) {
+			t.Errorf("ParseLang(%q) error = %v, want ErrUnknownLang", in, err)
+		}
+		if lang != LangAutoDetect {
+			t.Errorf("ParseLang(%q) = %q, want LangAutoDetect", in, lang)
+		}
+	}
+}
+
+func TestLangsRoundTrip(t *testing.T) {
+	for _, lang := range Langs() {
+		if !lang.Valid() {
+			t.Errorf("%q is not valid", lang)
+		}
+
+		for _, in := range []string{string(lang), lang.ISO6391(), lang.Name(), strings.ToUpper(lang.Name())} {
+			if in == "" {
+				continue
+			}
+			if got, err := ParseLang(in); err != nil || got != lang {
+				t.Errorf("ParseLang(%q) = %q, %v, want %q", in, got, err, lang)
+			}
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MirrorOptions are used to configure a mirror client and should be
+// passed to NewMirrorClient.
+type MirrorOptions struct {
+	// Settings of every replica client. Addr is replaced by the replica address.
+	UniversalOptions
+
+	// host:port addresses of the replicas holding the same index.
+	Addrs []string
+
+	// Frequency of PING commands sent to check replicas availability.
+	// Replica is considered down after 3 subsequent failed checks.
+	// Default is 500 milliseconds.
+	HeartbeatFrequency time.Duration
+}
+
+func (opt *MirrorOptions) init() {
+	if opt.HeartbeatFrequency == 0 {
+		opt.HeartbeatFrequency = 500 * time.Millisecond
+	}
+}
+
+func (opt *MirrorOptions) clientOptions(addr string) *UniversalOptions {
+	o := opt.UniversalOptions
+	o.Addr = addr
+	return &o
+}
+
+// ReplicaError reports the replicas a mirrored ingest command failed on.
+// The command succeeded on every other replica.
+type ReplicaError struct {
+	// Errors by replica address.
+	Failed map[string]error
+	Total  int
+}
+
+func (e *ReplicaError) Error() string {
+	addrs := make([]string, 0, len(e.Failed))
+	for addr := range e.Failed {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	msgs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		msgs[i] = addr + ": " + e.Failed[addr].Error()
+	}
+	return fmt.Sprintf("sonic: failed on %d of %d replicas: %s",
+		len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
+
+type mirrorReplica struct {
+	nodeHealth
+
+	Addr   string
+	Client *UniversalClient
+}
+
+//------------------------------------------------------------------------------
+
+// MirrorClient keeps identical Sonic servers in sync since Sonic has no
+// replication of its own. Every PUSH, POP and FLUSH* is sent to all
+// replicas, and failures are reported per replica with a *ReplicaError.
+// Search commands and COUNT are load-balanced across healthy replicas and
+// fail over to the next one when a replica can't be reached after retries.
+// It's safe for concurrent use by multiple goroutines.
+type MirrorClient struct {
+	baseCmdable
+	cmdable
+	ingestCmdable
+
+	opt      *MirrorOptions
+	replicas []*mirrorReplica
+	next     uint32 // atomic
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+var (
+	_ Cmdable       = (*MirrorClient)(nil)
+	_ IngestCmdable = (*MirrorClient)(nil)
+)
+
+func NewMirrorClient(opt *MirrorOptions) *MirrorClient {
+	opt.init()
+
+	c := MirrorClient{
+		opt:     opt,
+		closeCh: make(chan struct{}),
+	}
+	for _, addr := range opt.Addrs {
+		c.replicas = append(c.replicas, &mirrorReplica{
+			Addr:   addr,
+			Client: NewUniversalClient(opt.clientOptions(addr)),
+		})
+	}
+	c.baseCmdable = c.Process
+	c.cmdable = c.Process
+	c.ingestCmdable = c.Process
+
+	go c.heartbeat()
+
+	return &c
+}
+
+// heartbeat monitors state of each replica.
+func (c *MirrorClient) heartbeat() {
+	ticker := time.NewTicker(c.opt.HeartbeatFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closeCh:
+			return
+		}
+
+		for _, replica := range c.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), c.opt.HeartbeatFrequency)
+			err := replica.Client.Ping(ctx).Err()
+			cancel()
+
+			replica.Vote(err == nil)
+		}
+	}
+}
+
+// readReplicas returns the replicas to try for a read, healthy replicas
+// first starting with the next one in round-robin order.
+func (c *MirrorClient) readReplicas() []*mirrorReplica {
+	n := len(c.replicas)
+	start := int(atomic.AddUint32(&c.next, 1)) % n
+
+	up := make([]*mirrorReplica, 0, n)
+	var down []*mirrorReplica
+	for i := 0; i < n; i++ {
+		replica := c.replicas[(start+i)%n]
+		if replica.IsUp() {
+			up = append(up, replica)
+		} else {
+			down = append(down, replica)
+		}
+	}
+	return append(up, down...)
+}
+
+// Process sends PUSH, POP and FLUSH* to every replica and any other
+// command to a single healthy replica.
+func (c *MirrorClient) Process(ctx context.Context, cmd Cmder) error {
+	var retErr error
+	if len(c.replicas) == 0 {
+		retErr = errors.New("sonic: mirror has no replicas")
+	} else if isMirroredCmd(cmd.Name()) {
+		retErr = c.processAll(ctx, cmd)
+	} else {
+		retErr = c.processOne(ctx, cmd)
+	}
+	cmd.SetErr(retErr)
+	return retErr
+}
+
+func isMirroredCmd(name string) bool {
+	return contains([]string{CmdIngestPush, CmdIngestPop, CmdIngestFlushc, CmdIngestFlushb, CmdIngestFlusho}, strings.ToUpper(name))
+}
+
+func (c *MirrorClient) processOne(ctx context.Context, cmd Cmder) error {
+	var lastErr error
+	for _, replica := range c.readReplicas() {
+		lastErr = replica.Client.Process(ctx, cmd)
+		if !shouldFailover(ctx, lastErr) {
+			return lastErr
+		}
+		replica.Vote(false)
+	}
+	return lastErr
+}
+
+// shouldFailover reports whether a failed command may succeed on another
+// replica, i.e. the replica could not be reached rather than rejected it.
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if isSonicError(err) || errors.Is(err, ErrWrongChannel) {
+		return false
+	}
+	return true
+}
+
+func (c *MirrorClient) processAll(ctx context.Context, cmd Cmder) error {
+	cmds := make([]Cmder, len(c.replicas))
+	errs := make([]error, len(c.replicas))
+
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		cmds[i] = copyCmd(ctx, cmd)
+
+		wg.Add(1)
+		go func(i int, replica *mirrorReplica) {
+			defer wg.Done()
+			errs[i] = replica.Client.Process(ctx, cmds[i])
+		}(i, replica)
+	}
+	wg.Wait()
+
+	var firstOK = -1
+	failed := make(map[string]error)
+	for i, err := range errs {
+		if err != nil {
+			failed[c.replicas[i].Addr] = err
+		} else if firstOK == -1 {
+			firstOK = i
+		}
+	}
+	if firstOK >= 0 {
+		setCmdVal(cmd, cmds[firstOK])
+	}
+
+	if len(failed) > 0 {
+		return &ReplicaError{Failed: failed, Total: len(c.replicas)}
+	}
+	return nil
+}
+
+// copyCmd returns a new command with the same arguments as cmd.
+func copyCmd(ctx context.Context, cmd Cmder) Cmder {
+	switch cmd := cmd.(type) {
+	case *PushCmd:
+		return newPushCmd(ctx, cmd.qb)
+	case *PopCmd:
+		return newPopCmd(ctx, cmd.qb)
+	case *IntCmd:
+		return NewIntCmd(ctx, cmd.Args()...)
+	case *StatusCmd:
+		return NewStatusCmd(ctx, cmd.Args()...)
+	default:
+		return NewCmd(ctx, cmd.Args()...)
+	}
+}
+
+// setCmdVal copies the value of src, made by copyCmd, into dst.
+func setCmdVal(dst, src Cmder) {
+	switch dst := dst.(type) {
+	case *PushCmd:
+		dst.SetVal(src.(*PushCmd).Val())
+		dst.lines = src.(*PushCmd).lines
+	case *PopCmd:
+		dst.SetVal(src.(*PopCmd).Val())
+		dst.lines = src.(*PopCmd).lines
+	case *IntCmd:
+		dst.SetVal(src.(*IntCmd).Val())
+	case *StatusCmd:
+		dst.SetVal(src.(*StatusCmd).Val())
+	case *Cmd:
+		if src, ok := src.(*Cmd); ok {
+			dst.SetVal(src.Val())
+		}
+	}
+}
+
+// MPush pushes the items to every replica, see Client.MPush. An item
+// failed on some replicas reports a *ReplicaError.
+func (c *MirrorClient) MPush(ctx context.Context, items []IngestItem) *BatchCmd {
+	return c.processBatch(ctx, items, (*UniversalClient).MPush)
+}
+
+// MPop pops the items from every replica like MPush.
+func (c *MirrorClient) MPop(ctx context.Context, items []IngestItem) *BatchCmd {
+	return c.processBatch(ctx, items, (*UniversalClient).MPop)
+}
+
+func (c *MirrorClient) processBatch(
+	ctx context.Context, items []IngestItem,
+	fn func(*UniversalClient, context.Context, []IngestItem) *BatchCmd,
+) *BatchCmd {
+	cmd := newBatchCmd(ctx, items)
+
+	batches := make([]*BatchCmd, len(c.replicas))
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		wg.Add(1)
+		go func(i int, replica *mirrorReplica) {
+			defer wg.Done()
+			batches[i] = fn(replica.Client, ctx, items)
+		}(i, replica)
+	}
+	wg.Wait()
+
+	for idx := range cmd.val {
+		res := &cmd.val[idx]
+
+		failed := make(map[string]error)
+		for i, batch := range batches {
+			r := batch.Val()[idx]
+			if r.Err != nil {
+				failed[c.replicas[i].Addr] = r.Err
+			} else if res.Chunks == 0 {
+				res.Chunks = r.Chunks
+			}
+		}
+		if len(failed) > 0 {
+			res.Err = &ReplicaError{Failed: failed, Total: len(c.replicas)}
+		}
+	}
+
+	cmd.setBatchErr()
+	return cmd
+}
+
+// ForEachReplica concurrently calls the fn on each replica, including the
+// ones considered down. It returns the first error if any.
+func (c *MirrorClient) ForEachReplica(ctx context.Context, fn func(ctx context.Context, client *UniversalClient) error) error {
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	for _, replica := range c.replicas {
+		wg.Add(1)
+		go func(client *UniversalClient) {
+			defer wg.Done()
+			if err := fn(ctx, client); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}(replica.Client)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Close closes the clients of every replica.
+func (c *MirrorClient) Close() error {
+	var firstErr error
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		for _, replica := range c.replicas {
+			if err := replica.Client.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
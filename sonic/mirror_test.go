@@ -0,0 +1,58 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+func TestMirrorClientFailover(t *testing.T) {
+	ctx := context.Background()
+	srv1 := newTestServer(t)
+	srv2 := newTestServer(t)
+
+	mirror := NewMirrorClient(&MirrorOptions{
+		UniversalOptions: UniversalOptions{Options: Options{MaxRetries: -1}},
+		Addrs:            []string{srv1.Addr, srv2.Addr},
+	})
+	defer mirror.Close()
+
+	// Ingest commands go to every replica.
+	if err := mirror.Push(ctx, "c", "b", "obj:1", "hello world", LangNone).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	for _, srv := range []*sonictest.Server{srv1, srv2} {
+		if words := srv.Words("c", "b", "obj:1"); !reflect.DeepEqual(words, []string{"hello", "world"}) {
+			t.Fatalf("replica words = %q", words)
+		}
+	}
+
+	// Searches keep working on the remaining replica, whichever one the
+	// round-robin starts with.
+	srv1.Close()
+	for i := 0; i < 4; i++ {
+		ids, err := mirror.Query(ctx, "c", "b", "hello", 10, 0, LangNone).Result()
+		if err != nil {
+			t.Fatalf("Query %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(ids, []string{"obj:1"}) {
+			t.Fatalf("Query %d = %q", i, ids)
+		}
+	}
+
+	// Ingest reports the replica it failed on and still reaches the other.
+	err := mirror.Push(ctx, "c", "b", "obj:2", "second", LangNone).Err()
+	var replicaErr *ReplicaError
+	if !errors.As(err, &replicaErr) {
+		t.Fatalf("Push error = %v, want a *ReplicaError", err)
+	}
+	if _, ok := replicaErr.Failed[srv1.Addr]; !ok || len(replicaErr.Failed) != 1 || replicaErr.Total != 2 {
+		t.Fatalf("Push error = %v, want only %s failed", err, srv1.Addr)
+	}
+	if words := srv2.Words("c", "b", "obj:2"); !reflect.DeepEqual(words, []string{"second"}) {
+		t.Fatalf("obj:2 words = %q", words)
+	}
+}
@@ -0,0 +1,279 @@
+package sonic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/uretgec/go-sonic/pool"
+	"github.com/uretgec/go-sonic/proto"
+)
+
+type muxReply struct {
+	buf []byte
+	err error
+}
+
+type muxCall struct {
+	buf   []byte
+	reply chan muxReply
+
+	// Protected by MuxConn.mu.
+	marker    string // set once PENDING is read
+	abandoned bool   // the caller stopped waiting
+}
+
+// MuxConn is a search channel connection that keeps many QUERY and SUGGEST
+// commands in flight at the same time.
+//
+// Sonic answers every command with an immediate reply in order, and search
+// commands with a PENDING marker followed later by an EVENT carrying the same
+// marker, in any order. A background goroutine reads the connection and routes
+// every EVENT to the command waiting for its marker, so a handful of MuxConns
+// can serve thousands of concurrent searches. It's safe for concurrent use by
+// multiple goroutines.
+type MuxConn struct {
+	cmdable
+	baseCmdable
+
+	opt      *Options
+	connPool pool.Pooler
+	cn       *pool.Conn
+
+	// Serializes writes so the order of queue matches the order on the wire.
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	queue   []*muxCall          // waiting for the immediate reply
+	pending map[string]*muxCall // waiting for the EVENT of a marker
+	err     error               // set once the connection is unusable
+}
+
+// Mux opens a dedicated search connection that multiplexes concurrent
+// QUERY and SUGGEST commands. The connection is not part of the pool and
+// must be closed with MuxConn.Close.
+func (c *Client) Mux(ctx context.Context) (*MuxConn, error) {
+	if c.opt.ChannelMode != ChannelSearch {
+		return nil, errors.New("sonic: Mux requires the search channel")
+	}
+
+	cn, err := c.connPool.NewConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.initConn(ctx, cn); err != nil {
+		_ = c.connPool.CloseConn(cn)
+		return nil, err
+	}
+
+	m := &MuxConn{
+		opt:      c.opt,
+		connPool: c.connPool,
+		cn:       cn,
+		pending:  make(map[string]*muxCall),
+	}
+	m.cmdable = m.Process
+	m.baseCmdable = m.Process
+
+	go m.readLoop()
+
+	return m, nil
+}
+
+func (c *MuxConn) Process(ctx context.Context, cmd Cmder) error {
+	if err := CheckChannel(cmd.Name(), ChannelSearch); err != nil {
+		cmd.SetErr(err)
+		return err
+	}
+
+	if c.opt.LangDetector != nil {
+		detectLang(c.opt.LangDetector, cmd)
+	}
+
+	var retErr error
+	if c.opt.QueryCache != nil {
+		retErr = c.opt.QueryCache.process(ctx, cmd, c.process)
+	} else {
+		retErr = c.process(ctx, cmd)
+	}
+	cmd.SetErr(retErr)
+	return retErr
+}
+
+func (c *MuxConn) process(ctx context.Context, cmd Cmder) error {
+	if c.opt.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opt.ReadTimeout)
+		defer cancel()
+	}
+
+	call := &muxCall{
+		reply: make(chan muxReply, 1),
+	}
+
+	c.writeMu.Lock()
+	if err := c.enqueue(call); err != nil {
+		c.writeMu.Unlock()
+		return err
+	}
+	err := c.cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+		return writeCmd(wr, cmd, c.cn.BufferSize)
+	})
+	c.writeMu.Unlock()
+	if err != nil {
+		// A partially written line leaves the stream in an unknown state.
+		c.fail(err)
+		return err
+	}
+
+	select {
+	case rep := <-call.reply:
+		if rep.err != nil {
+			return rep.err
+		}
+		// Replay the routed lines through the command's own reply parser.
+		return cmd.readReply(proto.NewReader(bytes.NewReader(rep.buf)))
+	case <-ctx.Done():
+		c.abandon(call)
+		return ctx.Err()
+	}
+}
+
+// abandon forgets a call whose caller stopped waiting. A call still waiting
+// for its immediate reply stays queued to keep the queue in step with the
+// wire, but its marker is never registered.
+func (c *MuxConn) abandon(call *muxCall) {
+	c.mu.Lock()
+	call.abandoned = true
+	if call.marker != "" && c.pending[call.marker] == call {
+		delete(c.pending, call.marker)
+	}
+	c.mu.Unlock()
+}
+
+func (c *MuxConn) enqueue(call *muxCall) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	c.queue = append(c.queue, call)
+	return nil
+}
+
+func (c *MuxConn) readLoop() {
+	// A negative timeout clears the deadline left on the connection by START.
+	err := c.cn.WithReader(context.Background(), -1, func(rd *proto.Reader) error {
+		for {
+			line, err := rd.ReadLine()
+			if err != nil {
+				return err
+			}
+			c.dispatch(line)
+		}
+	})
+	c.fail(err)
+}
+
+func (c *MuxConn) dispatch(line []byte) {
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return
+	}
+
+	buf := make([]byte, 0, len(line)+2)
+	buf = append(append(buf, line...), '\r', '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch fields[0] {
+	case proto.ConnectedReply:
+		// Banner sent once when the connection is opened.
+	case proto.PendingReply:
+		call := c.shift()
+		if call == nil {
+			return
+		}
+		if len(fields) < 2 {
+			call.reply <- muxReply{err: fmt.Errorf("sonic: invalid reply: %q", line)}
+			return
+		}
+		if call.abandoned {
+			// Its EVENT is dropped below as the marker is unknown.
+			return
+		}
+		call.buf = buf
+		call.marker = fields[1]
+		c.pending[call.marker] = call
+	case proto.EventReply:
+		// EVENT <QUERY|SUGGEST> <marker> [results...]
+		if len(fields) < 3 {
+			return
+		}
+		call, ok := c.pending[fields[2]]
+		if !ok {
+			// Late EVENT of an abandoned call.
+			return
+		}
+		delete(c.pending, fields[2])
+		call.reply <- muxReply{buf: append(call.buf, buf...)}
+	default:
+		call := c.shift()
+		if call == nil {
+			return
+		}
+		call.reply <- muxReply{buf: buf}
+	}
+}
+
+func (c *MuxConn) shift() *muxCall {
+	if len(c.queue) == 0 {
+		return nil
+	}
+	call := c.queue[0]
+	c.queue[0] = nil
+	c.queue = c.queue[1:]
+	return call
+}
+
+// fail marks the connection unusable and releases every waiting command.
+func (c *MuxConn) fail(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	queue, pending := c.queue, c.pending
+	c.queue, c.pending = nil, nil
+	c.mu.Unlock()
+
+	_ = c.connPool.CloseConn(c.cn)
+
+	for _, call := range queue {
+		call.reply <- muxReply{err: err}
+	}
+	for _, call := range pending {
+		call.reply <- muxReply{err: err}
+	}
+}
+
+// InFlight returns the number of commands still waiting for a reply.
+func (c *MuxConn) InFlight() int {
+	c.mu.Lock()
+	n := len(c.queue) + len(c.pending)
+	c.mu.Unlock()
+	return n
+}
+
+// Close closes the connection and fails every command still in flight.
+func (c *MuxConn) Close() error {
+	c.fail(ErrClosed)
+	return nil
+}
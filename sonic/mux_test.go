@@ -0,0 +1,196 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMuxConcurrentQueries(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	const n = 50
+	for i := 0; i < n; i++ {
+		text := fmt.Sprintf("word%d common", i)
+		if err := ingest.Push(ctx, "c", "b", fmt.Sprintf("obj:%d", i), text, LangNone).Err(); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	mux, err := search.Mux(ctx)
+	if err != nil {
+		t.Fatalf("Mux: %v", err)
+	}
+	defer mux.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids, err := mux.Query(ctx, "c", "b", fmt.Sprintf("word%d", i), 10, 0, LangNone).Result()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("obj:%d", i); len(ids) != 1 || ids[0] != want {
+				errs <- fmt.Errorf("word%d: got %q, want [%s]", i, ids, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n := mux.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d after all replies", n)
+	}
+}
+
+func TestMuxRejectsIngest(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	mux, err := search.Mux(ctx)
+	if err != nil {
+		t.Fatalf("Mux: %v", err)
+	}
+	defer mux.Close()
+
+	cmd := newPushCmd(ctx, QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: "t"})
+	if err := mux.Process(ctx, cmd); err == nil {
+		t.Fatal("PUSH succeeded on a MuxConn")
+	}
+}
+
+// waitInFlight waits until mux has n commands in flight.
+func waitInFlight(t *testing.T, mux *MuxConn, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); mux.InFlight() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("InFlight = %d, want %d", mux.InFlight(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMuxMarkerRouting(t *testing.T) {
+	ctx := context.Background()
+
+	// The second query is answered first.
+	search, mock := newMockClient(t, `
+> QUERY c b "first"
+< PENDING AAAAAAAA
+> QUERY c b "second"
+< PENDING BBBBBBBB
+< EVENT QUERY BBBBBBBB obj:2
+< EVENT SUGGEST CCCCCCCC unknown
+< EVENT QUERY AAAAAAAA obj:1a obj:1b
+`)
+
+	mux, err := search.Mux(ctx)
+	if err != nil {
+		t.Fatalf("Mux: %v", err)
+	}
+	defer mux.Close()
+
+	first := make(chan *QueryCmd, 1)
+	go func() {
+		first <- mux.Query(ctx, "c", "b", "first", 0, 0, LangAutoDetect)
+	}()
+	// Writes are serialized, so once the first query is queued it is
+	// written before the second one.
+	waitInFlight(t, mux, 1)
+
+	second := mux.Query(ctx, "c", "b", "second", 0, 0, LangAutoDetect)
+	if ids, err := second.Result(); err != nil || !reflect.DeepEqual(ids, []string{"obj:2"}) {
+		t.Fatalf("second query = %q, %v", ids, err)
+	}
+	if ids, err := (<-first).Result(); err != nil || !reflect.DeepEqual(ids, []string{"obj:1a", "obj:1b"}) {
+		t.Fatalf("first query = %q, %v", ids, err)
+	}
+	if n := mux.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d after all replies", n)
+	}
+
+	mux.Close()
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMuxCanceledQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{
+			name: "waiting for PENDING",
+			script: `
+> QUERY c b "slow"
+! sleep 100ms
+< PENDING AAAAAAAA
+`,
+		},
+		{
+			name: "waiting for EVENT",
+			script: `
+> QUERY c b "slow"
+< PENDING AAAAAAAA
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// The late EVENT of the canceled query must be dropped.
+			search, mock := newMockClient(t, tt.script+`
+> QUERY c b "fast"
+< EVENT QUERY AAAAAAAA obj:late
+< PENDING BBBBBBBB
+< EVENT QUERY BBBBBBBB obj:fast
+`)
+
+			mux, err := search.Mux(ctx)
+			if err != nil {
+				t.Fatalf("Mux: %v", err)
+			}
+			defer mux.Close()
+
+			cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			err = mux.Query(cctx, "c", "b", "slow", 0, 0, LangAutoDetect).Err()
+			cancel()
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+			waitInFlight(t, mux, 0)
+
+			ids, err := mux.Query(ctx, "c", "b", "fast", 0, 0, LangAutoDetect).Result()
+			if err != nil || !reflect.DeepEqual(ids, []string{"obj:fast"}) {
+				t.Fatalf("fast query = %q, %v", ids, err)
+			}
+			if n := mux.InFlight(); n != 0 {
+				t.Fatalf("InFlight = %d after all replies", n)
+			}
+
+			mux.Close()
+			mock.Close()
+			if err := mock.Err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
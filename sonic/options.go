@@ -85,8 +85,22 @@ type Options struct {
 	// Max Buffered Size
 	// Default: 20000
 	// Real buffer size return from first connect sonic server response
-	// STARTED search protocol(1) buffer(20000)
+	// STARTED search protocol(1) buffer(20000) and kept per connection.
+	// This value is used for connections that did not negotiate one.
 	MaxBufferedSize int
+
+	// Maximum number of items MPush and MPop send at the same time.
+	// Default is PoolSize.
+	BatchConcurrency int
+
+	// Cache of QUERY and SUGGEST results, invalidated by the PUSH, POP and
+	// FLUSH* commands of clients sharing it. Default is no cache.
+	QueryCache *QueryCache
+
+	// Detects the language of PUSH and QUERY commands sent with
+	// LangAutoDetect, see the langdetect package. Default is to let Sonic
+	// detect it.
+	LangDetector LangDetector
 }
 
 func (opt *Options) init() {
@@ -161,6 +175,10 @@ func (opt *Options) init() {
 		opt.MaxBufferedSize = 20000
 	}
 
+	if opt.BatchConcurrency == 0 {
+		opt.BatchConcurrency = opt.PoolSize
+	}
+
 	/*opt.OnConnect = func(ctx context.Context, cn *Conn) error {
 		// Connect Sonic Server First Time
 		bufferSize, err := cn.Start(ctx, opt.ChannelMode, opt.AuthPassword).Int()
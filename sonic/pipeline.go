@@ -0,0 +1,215 @@
+package sonic
+
+import (
+	"context"
+	"sync"
+
+	"github.com/uretgec/go-sonic/pool"
+	"github.com/uretgec/go-sonic/proto"
+)
+
+type pipelineExecer func(context.Context, []Cmder) error
+
+// Pipeliner is a mechanism to realise Sonic pipelining.
+//
+// Queued ingest commands are written to a single connection with one flush
+// and their replies are read back in order, saving a round trip per command.
+// Pipelining is meant for bulk PUSH/POP/FLUSH*/COUNT workloads such as
+// reindexing a bucket.
+type Pipeliner interface {
+	Push(ctx context.Context, collection, bucket, object, text string, lang Lang) *PushCmd
+	Pop(ctx context.Context, collection, bucket, object, text string) *PopCmd
+	Count(ctx context.Context, collection, bucket, object string) *IntCmd
+	FlushCollection(ctx context.Context, collection string) *IntCmd
+	FlushBucket(ctx context.Context, collection, bucket string) *IntCmd
+	FlushObject(ctx context.Context, collection, bucket, object string) *IntCmd
+
+	Len() int
+	Do(ctx context.Context, args ...string) *Cmd
+	Process(ctx context.Context, cmd Cmder) error
+	Discard()
+	Exec(ctx context.Context) ([]Cmder, error)
+	Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error)
+}
+
+var _ Pipeliner = (*Pipeline)(nil)
+
+// Pipeline implements pipelining as described in
+// https://redis.io/topics/pipelining. It's safe for concurrent use
+// by multiple goroutines.
+type Pipeline struct {
+	ingestCmdable
+
+	ctx  context.Context
+	exec pipelineExecer
+
+	mu   sync.Mutex
+	cmds []Cmder
+}
+
+func (c *Pipeline) init() {
+	c.ingestCmdable = c.Process
+}
+
+// Len returns the number of queued commands.
+func (c *Pipeline) Len() int {
+	c.mu.Lock()
+	ln := len(c.cmds)
+	c.mu.Unlock()
+	return ln
+}
+
+// Do queues a custom command for later execution.
+func (c *Pipeline) Do(ctx context.Context, args ...string) *Cmd {
+	cmd := NewCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
+// Process queues the cmd for later execution.
+func (c *Pipeline) Process(ctx context.Context, cmd Cmder) error {
+	c.mu.Lock()
+	c.cmds = append(c.cmds, cmd)
+	c.mu.Unlock()
+	return nil
+}
+
+// Pipelined runs fn with the pipeline and then executes the queued commands.
+func (c *Pipeline) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	if err := fn(c); err != nil {
+		return nil, err
+	}
+	return c.Exec(ctx)
+}
+
+// Discard resets the pipeline and discards queued commands.
+func (c *Pipeline) Discard() {
+	c.mu.Lock()
+	c.cmds = c.cmds[:0]
+	c.mu.Unlock()
+}
+
+// Exec executes all previously queued commands using one
+// client-server roundtrip.
+//
+// Exec always returns list of commands and error of the first failed
+// command if any.
+func (c *Pipeline) Exec(ctx context.Context) ([]Cmder, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cmds) == 0 {
+		return nil, nil
+	}
+
+	cmds := c.cmds
+	c.cmds = nil
+
+	return cmds, c.exec(ctx, cmds)
+}
+
+//------------------------------------------------------------------------------
+
+func (c *baseClient) processPipeline(ctx context.Context, cmds []Cmder) error {
+	// Commands of other channels fail without being sent.
+	all := cmds
+	cmds = make([]Cmder, 0, len(all))
+	for _, cmd := range all {
+		if err := CheckChannel(cmd.Name(), c.opt.ChannelMode); err != nil {
+			cmd.SetErr(err)
+			continue
+		}
+		if c.opt.LangDetector != nil {
+			detectLang(c.opt.LangDetector, cmd)
+		}
+		cmds = append(cmds, cmd)
+	}
+	if len(cmds) == 0 {
+		return cmdsFirstErr(all)
+	}
+
+	if cache := c.opt.QueryCache; cache != nil {
+		defer func() {
+			for _, cmd := range cmds {
+				cache.invalidateCmd(cmd)
+			}
+		}()
+	}
+
+	// Commands answered before a connection failure are done and are never
+	// sent again: a second FLUSH*, POP or COUNT would overwrite their reply.
+	// Only the commands still waiting for a reply are retried.
+	var lastErr error
+	pending := cmds
+	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+				setCmdsErr(cmds, err)
+				return err
+			}
+		}
+
+		lastErr = c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
+			err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+				return writeCmds(wr, pending, c.bufferSize(cn))
+			})
+			if err != nil {
+				return err
+			}
+
+			return cn.WithReader(ctx, c.opt.ReadTimeout, func(rd *proto.Reader) error {
+				n, err := pipelineReadCmds(rd, pending)
+				pending = pending[n:]
+				return err
+			})
+		})
+		if lastErr == nil || !shouldRetry(lastErr, true) {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		setCmdsErr(pending, lastErr)
+		return lastErr
+	}
+	return cmdsFirstErr(all)
+}
+
+func writeCmds(wr *proto.Writer, cmds []Cmder, bufferSize int) error {
+	for _, cmd := range cmds {
+		if err := writeCmd(wr, cmd, bufferSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pipelineReadCmds reads the replies of cmds in order and returns the number
+// of commands that got one.
+func pipelineReadCmds(rd *proto.Reader, cmds []Cmder) (int, error) {
+	for i, cmd := range cmds {
+		err := cmd.readReply(rd)
+		cmd.SetErr(err)
+		if err != nil && !isSonicError(err) {
+			return i, err
+		}
+	}
+	return len(cmds), nil
+}
+
+func setCmdsErr(cmds []Cmder, e error) {
+	for _, cmd := range cmds {
+		if cmd.Err() == nil {
+			cmd.SetErr(e)
+		}
+	}
+}
+
+func cmdsFirstErr(cmds []Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+func TestPipelined(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+
+	cmds, err := ingest.Pipelined(ctx, func(pipe Pipeliner) error {
+		pipe.Push(ctx, "messages", "default", "msg:1", "first text", LangEng)
+		pipe.Push(ctx, "messages", "default", "msg:2", "second text", LangEng)
+		pipe.Count(ctx, "messages", "default", "")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pipelined: %v", err)
+	}
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, want 3", len(cmds))
+	}
+	if n := cmds[2].(*IntCmd).Val(); n != 3 {
+		t.Fatalf("COUNT = %d, want 3", n)
+	}
+	if words := srv.Words("messages", "default", "msg:2"); !reflect.DeepEqual(words, []string{"second", "text"}) {
+		t.Fatalf("msg:2 words = %q", words)
+	}
+}
+
+func TestPipelinedWrongChannel(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+
+	cmds, err := ingest.Pipelined(ctx, func(pipe Pipeliner) error {
+		pipe.Push(ctx, "messages", "default", "msg:1", "text", LangEng)
+		pipe.Process(ctx, NewQueryCmd(ctx, "QUERY", "messages", "default", `"text"`))
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Pipelined succeeded with a search command")
+	}
+	if err := cmds[0].Err(); err != nil {
+		t.Fatalf("PUSH: %v", err)
+	}
+	if err := cmds[1].Err(); err == nil {
+		t.Fatal("QUERY succeeded on the ingest channel")
+	}
+}
+
+func TestPipelineRetriesUnansweredCommands(t *testing.T) {
+	ctx := context.Background()
+
+	// The connection drops after the FLUSHB reply: only COUNT is sent again.
+	steps, err := sonictest.ParseTranscript(strings.NewReader(`
+< CONNECTED <sonic-server v1.4.0>
+> START ingest SecretPassword
+< STARTED ingest protocol(1) buffer(20000)
+> PUSH messages default msg:1 "hello"
+< OK
+> FLUSHB messages default
+< RESULT 3
+> COUNT messages default
+! drop
+! connect
+< CONNECTED <sonic-server v1.4.0>
+> START ingest SecretPassword
+< STARTED ingest protocol(1) buffer(20000)
+> COUNT messages default
+< RESULT 0
+`))
+	if err != nil {
+		t.Fatalf("ParseTranscript: %v", err)
+	}
+	mock := sonictest.NewMockServer(steps)
+	defer mock.Close()
+
+	ingest := NewClient(&Options{
+		Addr:         mock.Addr,
+		AuthPassword: "SecretPassword",
+		ChannelMode:  ChannelIngest,
+		PoolSize:     1,
+	})
+	defer ingest.Close()
+
+	cmds, err := ingest.Pipelined(ctx, func(pipe Pipeliner) error {
+		pipe.Push(ctx, "messages", "default", "msg:1", "hello", LangAutoDetect)
+		pipe.FlushBucket(ctx, "messages", "default")
+		pipe.Count(ctx, "messages", "default", "")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pipelined: %v", err)
+	}
+	if n := cmds[1].(*IntCmd).Val(); n != 3 {
+		t.Errorf("FLUSHB = %d, want 3", n)
+	}
+	if n := cmds[2].(*IntCmd).Val(); n != 0 {
+		t.Errorf("COUNT = %d, want 0", n)
+	}
+
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
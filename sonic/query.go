@@ -0,0 +1,90 @@
+package sonic
+
+import (
+	"context"
+	"fmt"
+)
+
+// SearchQuery builds a validated QUERY command, e.g.
+//
+//	ids, err := sonic.Query("messages", "default").Terms("hello").Limit(10).Do(ctx, client).Result()
+//
+// The first invalid value is reported by Build and Do as an error wrapping
+// ErrQueryBuilder, and no command is sent.
+type SearchQuery struct {
+	qb  QueryBuilder
+	err error
+}
+
+// Query starts a QUERY command on the bucket of a collection.
+func Query(collection, bucket string) *SearchQuery {
+	q := &SearchQuery{}
+	q.qb.Command = CmdSearchQuery
+	q.qb.Collection = collection
+	q.qb.Bucket = bucket
+	return q
+}
+
+func (q *SearchQuery) setErr(err error) *SearchQuery {
+	if q.err == nil {
+		q.err = err
+	}
+	return q
+}
+
+// Terms sets the searched text.
+func (q *SearchQuery) Terms(terms string) *SearchQuery {
+	q.qb.Text = terms
+	return q
+}
+
+// Limit sets the maximum number of results, 0 for the server default.
+func (q *SearchQuery) Limit(limit int) *SearchQuery {
+	if limit < 0 || limit > MaxLimit {
+		return q.setErr(fmt.Errorf("%w: limit %d out of range [0, %d]", ErrQueryBuilder, limit, MaxLimit))
+	}
+	q.qb.Limit = limit
+	return q
+}
+
+// Offset sets the number of results to skip.
+func (q *SearchQuery) Offset(offset int) *SearchQuery {
+	if offset < 0 || int64(offset) > MaxOffset {
+		return q.setErr(fmt.Errorf("%w: offset %d out of range [0, %d]", ErrQueryBuilder, offset, MaxOffset))
+	}
+	q.qb.Offset = offset
+	return q
+}
+
+// Lang sets the ISO 639-3 locale of the terms, LangNone to disable stop
+// words or LangAutoDetect to let Sonic detect it.
+func (q *SearchQuery) Lang(lang Lang) *SearchQuery {
+	if err := lang.validate(); err != nil {
+		return q.setErr(fmt.Errorf("%w: %v", ErrQueryBuilder, err))
+	}
+	q.qb.Lang = string(lang)
+	return q
+}
+
+// Build returns the validated QueryBuilder of the command.
+func (q *SearchQuery) Build() (QueryBuilder, error) {
+	if q.err != nil {
+		return QueryBuilder{}, q.err
+	}
+	if err := q.qb.Validate(); err != nil {
+		return QueryBuilder{}, err
+	}
+	return q.qb, nil
+}
+
+// Do sends the command through c, or returns a QueryCmd holding the
+// validation error without sending anything.
+func (q *SearchQuery) Do(ctx context.Context, c Cmdable) *QueryCmd {
+	qb, err := q.Build()
+	if err != nil {
+		cmd := NewQueryCmd(ctx, q.qb.Encode()...)
+		cmd.SetErr(err)
+		return cmd
+	}
+	return c.Query(ctx, qb.Collection, qb.Bucket, qb.Text, qb.Limit, qb.Offset, Lang(qb.Lang))
+}
@@ -0,0 +1,80 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSearchQueryBuild(t *testing.T) {
+	qb, err := Query("c", "b").Terms("hello").Limit(5).Offset(10).Lang(LangEng).Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := QueryBuilder{Command: CmdSearchQuery, Collection: "c", Bucket: "b", Text: "hello", Limit: 5, Offset: 10, Lang: "eng"}
+	if qb != want {
+		t.Fatalf("Build = %+v, want %+v", qb, want)
+	}
+	if got, want := qb.Encode(), []string{"QUERY", "c", "b", `"hello"`, "LIMIT(5)", "OFFSET(10)", "LANG(eng)"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Encode = %q, want %q", got, want)
+	}
+}
+
+func TestSearchQueryInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *SearchQuery
+	}{
+		{"no collection", Query("", "b").Terms("hello")},
+		{"no bucket", Query("c", "").Terms("hello")},
+		{"no terms", Query("c", "b")},
+		{"blank terms", Query("c", "b").Terms("  ")},
+		{"space in collection", Query("my coll", "b").Terms("hello")},
+		{"quote in bucket", Query("c", `b"`).Terms("hello")},
+		{"negative limit", Query("c", "b").Terms("hello").Limit(-1)},
+		{"limit too large", Query("c", "b").Terms("hello").Limit(MaxLimit + 1)},
+		{"negative offset", Query("c", "b").Terms("hello").Offset(-1)},
+		{"offset too large", Query("c", "b").Terms("hello").Offset(MaxOffset + 1)},
+		{"unknown lang", Query("c", "b").Terms("hello").Lang("xx")},
+		// A later valid value doesn't clear the first error.
+		{"limit fixed later", Query("c", "b").Terms("hello").Limit(-1).Limit(5)},
+	}
+
+	// Nothing listens there, a command sent would fail to dial.
+	client := NewClient(&Options{Addr: "127.0.0.1:1", ChannelMode: ChannelSearch, MaxRetries: -1})
+	defer client.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.q.Build(); !errors.Is(err, ErrQueryBuilder) {
+				t.Fatalf("Build error = %v, want ErrQueryBuilder", err)
+			}
+			if err := tt.q.Do(context.Background(), client).Err(); !errors.Is(err, ErrQueryBuilder) {
+				t.Fatalf("Do error = %v, want ErrQueryBuilder", err)
+			}
+		})
+	}
+}
+
+func TestSearchQueryDo(t *testing.T) {
+	client, mock := newMockClient(t, `
+> QUERY c b "say \"hi\"" LIMIT(5) OFFSET(10) LANG(eng)
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd obj:1 obj:2
+`)
+
+	ids, err := Query("c", "b").Terms(`say "hi"`).Limit(5).Offset(10).Lang(LangEng).Do(context.Background(), client).Result()
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if want := []string{"obj:1", "obj:2"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Do = %q, want %q", ids, want)
+	}
+
+	_ = client.Close()
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatalf("mock: %v", err)
+	}
+}
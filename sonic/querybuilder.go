@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/uretgec/go-sonic/proto"
 )
 
-var ErrQueryBuilder = errors.New("struct not correct")
+var ErrQueryBuilder = errors.New("sonic: invalid query builder")
 
 type QueryBuilder struct {
 	Mode     string `json:"mode,omitempty" redis:"mode"`
@@ -47,41 +51,42 @@ func (sb QueryBuilder) FindSuggestWord(words string) int {
 	return strings.Index(words, " ")
 }
 
+// Encode returns the command line arguments of qb. Arguments are emitted
+// whenever they are set, see Validate for the ones a command requires.
+// LIMIT and OFFSET are only emitted when positive, Sonic defaults apply
+// otherwise.
 func (qb QueryBuilder) Encode() []string {
 	var args []string
 	if qb.Command != "" {
 		args = append(args, qb.Command)
 	}
 
-	if contains([]string{CmdSearchQuery, CmdSearchSuggest, CmdIngestPush, CmdIngestPop, CmdIngestCount, CmdIngestFlushc, CmdIngestFlushb, CmdIngestFlusho}, qb.Command) && qb.Collection != "" {
-		args = append(args, qb.Collection)
-
-		if qb.Bucket != "" {
-			args = append(args, qb.Bucket)
-
-			if qb.Object != "" {
-				args = append(args, qb.Object)
+	switch qb.Command {
+	case CmdSearchQuery, CmdSearchSuggest, CmdSearchList,
+		CmdIngestPush, CmdIngestPop, CmdIngestCount,
+		CmdIngestFlushc, CmdIngestFlushb, CmdIngestFlusho:
+		for _, arg := range []string{qb.Collection, qb.Bucket, qb.Object} {
+			if arg != "" {
+				args = append(args, arg)
 			}
+		}
 
-			if contains([]string{CmdSearchQuery, CmdSearchSuggest, CmdIngestPush, CmdIngestPop}, qb.Command) && qb.Text != "" {
-				args = append(args, fmt.Sprintf("\"%s\"", qb.Text))
+		if contains([]string{CmdSearchQuery, CmdSearchSuggest, CmdIngestPush, CmdIngestPop}, qb.Command) && qb.Text != "" {
+			args = append(args, proto.QuoteText(qb.Text))
+		}
 
-				if contains([]string{CmdSearchQuery, CmdSearchSuggest}, qb.Command) {
-					if qb.Limit != -1 {
-						args = append(args, "LIMIT("+strconv.Itoa(qb.Limit)+")")
-					}
+		if contains([]string{CmdSearchQuery, CmdSearchSuggest, CmdSearchList}, qb.Command) && qb.Limit > 0 {
+			args = append(args, "LIMIT("+strconv.Itoa(qb.Limit)+")")
+		}
 
-					if qb.Command == CmdSearchQuery && qb.Offset != -1 {
-						args = append(args, "OFFSET("+strconv.Itoa(qb.Offset)+")")
-					}
-				}
+		if contains([]string{CmdSearchQuery, CmdSearchList}, qb.Command) && qb.Offset > 0 {
+			args = append(args, "OFFSET("+strconv.Itoa(qb.Offset)+")")
+		}
 
-				if contains([]string{CmdSearchQuery, CmdIngestPush}, qb.Command) && qb.Lang != "" {
-					args = append(args, "LANG("+qb.Lang+")")
-				}
-			}
+		if contains([]string{CmdSearchQuery, CmdIngestPush}, qb.Command) && qb.Lang != "" {
+			args = append(args, "LANG("+qb.Lang+")")
 		}
-	} else if CmdControlTrigger == qb.Command {
+	case CmdControlTrigger:
 		if qb.Action != "" {
 			args = append(args, qb.Action)
 		}
@@ -89,7 +94,7 @@ func (qb QueryBuilder) Encode() []string {
 		if qb.Data != "" {
 			args = append(args, qb.Data)
 		}
-	} else if CmdSearchStart == qb.Command {
+	case CmdSearchStart:
 		if qb.Mode != "" {
 			args = append(args, qb.Mode)
 		}
@@ -102,6 +107,195 @@ func (qb QueryBuilder) Encode() []string {
 	return args
 }
 
+// Maximum LIMIT and OFFSET values, the sizes Sonic parses them into.
+const (
+	MaxLimit  = 1<<16 - 1
+	MaxOffset = 1<<32 - 1
+)
+
+// Validate checks that qb has every argument its command requires, that
+// identifiers are valid and that limit, offset and lang are in range.
+// Errors wrap ErrQueryBuilder.
+func (qb QueryBuilder) Validate() error {
+	var required []string
+	switch qb.Command {
+	case CmdSearchQuery, CmdSearchSuggest:
+		required = []string{"collection", "bucket", "text"}
+	case CmdSearchList, CmdIngestFlushb:
+		required = []string{"collection", "bucket"}
+	case CmdIngestPush, CmdIngestPop:
+		required = []string{"collection", "bucket", "object", "text"}
+	case CmdIngestCount, CmdIngestFlushc:
+		required = []string{"collection"}
+	case CmdIngestFlusho:
+		required = []string{"collection", "bucket", "object"}
+	case "":
+		return fmt.Errorf("%w: no command", ErrQueryBuilder)
+	}
+
+	values := map[string]string{
+		"collection": qb.Collection,
+		"bucket":     qb.Bucket,
+		"object":     qb.Object,
+		"text":       strings.TrimSpace(qb.Text),
+	}
+	for _, name := range required {
+		if values[name] == "" {
+			return fmt.Errorf("%w: %s requires a %s", ErrQueryBuilder, qb.Command, name)
+		}
+	}
+	if qb.Object != "" && qb.Bucket == "" {
+		return fmt.Errorf("%w: object %q requires a bucket", ErrQueryBuilder, qb.Object)
+	}
+
+	for _, name := range []string{"collection", "bucket", "object"} {
+		if err := validateIdentifier(name, values[name]); err != nil {
+			return err
+		}
+	}
+
+	if qb.Limit < 0 || qb.Limit > MaxLimit {
+		return fmt.Errorf("%w: limit %d out of range [0, %d]", ErrQueryBuilder, qb.Limit, MaxLimit)
+	}
+	if qb.Offset < 0 || int64(qb.Offset) > MaxOffset {
+		return fmt.Errorf("%w: offset %d out of range [0, %d]", ErrQueryBuilder, qb.Offset, MaxOffset)
+	}
+	if err := Lang(qb.Lang).validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrQueryBuilder, err)
+	}
+
+	return nil
+}
+
+// validateIdentifier checks a collection, bucket or object name, which
+// can't hold spaces, quotes or control characters.
+func validateIdentifier(name, value string) error {
+	for _, r := range value {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '"' {
+			return fmt.Errorf("%w: invalid character %q in %s %q", ErrQueryBuilder, r, name, value)
+		}
+	}
+	return nil
+}
+
+// EncodeChunks encodes the command like Encode, splitting Text on word
+// boundaries into as many command lines as needed so that every full line,
+// escaped text and line ending included, fits in bufferSize bytes.
+func (qb QueryBuilder) EncodeChunks(bufferSize int) ([][]string, error) {
+	args := qb.Encode()
+	if qb.Text == "" || lineSize(args) <= bufferSize {
+		return [][]string{args}, nil
+	}
+
+	// Everything but the text itself: command prefix, quotes, suffix and CRLF.
+	probe := qb
+	probe.Text = "x"
+	budget := bufferSize - (lineSize(probe.Encode()) - 1)
+	if budget < utf8.UTFMax*2 {
+		return nil, fmt.Errorf("sonic: buffer of %d bytes is too small for %s", bufferSize, qb.Command)
+	}
+
+	var chunks [][]string
+	for _, text := range splitWords(qb.Text, budget) {
+		chunk := qb
+		chunk.Text = text
+		chunks = append(chunks, chunk.Encode())
+	}
+	if len(chunks) == 0 {
+		return [][]string{args}, nil
+	}
+	return chunks, nil
+}
+
+// lineSize returns the size of the command line written for args.
+func lineSize(args []string) int {
+	n := len("\r\n")
+	for i, arg := range args {
+		if i > 0 {
+			n++
+		}
+		n += len(arg)
+	}
+	return n
+}
+
+// splitWords cuts text between words into chunks whose escaped size is at
+// most budget bytes, keeping the original separators inside each chunk.
+// Words longer than budget are cut between runes; a rune escaping to more
+// than budget bytes still gets a chunk of its own.
+func splitWords(text string, budget int) []string {
+	var chunks []string
+	var cur strings.Builder
+	var curSize int
+
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			curSize = 0
+		}
+	}
+
+	for {
+		// The separators before the next word, then the word itself.
+		i := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) })
+		if i < 0 {
+			break
+		}
+		sep := text[:i]
+		text = text[i:]
+		j := strings.IndexFunc(text, unicode.IsSpace)
+		if j < 0 {
+			j = len(text)
+		}
+		word := text[:j]
+		text = text[j:]
+
+		size := escapedSize(word)
+		for size > budget {
+			// Cut the longest prefix of the word that fits on its own.
+			flush()
+			var n, cut int
+			for i, r := range word {
+				rs := escapedSize(string(r))
+				if n+rs > budget {
+					cut = i
+					break
+				}
+				n += rs
+			}
+			if cut == 0 {
+				// Always cut at least one rune.
+				_, cut = utf8.DecodeRuneInString(word)
+			}
+			chunks = append(chunks, word[:cut])
+			word = word[cut:]
+			size = escapedSize(word)
+		}
+
+		// Separators are kept as is inside a chunk and dropped between
+		// chunks.
+		sepSize := escapedSize(sep)
+		if curSize > 0 && curSize+sepSize+size > budget {
+			flush()
+		}
+		if curSize > 0 {
+			cur.WriteString(sep)
+			curSize += sepSize
+		}
+		cur.WriteString(word)
+		curSize += size
+	}
+	flush()
+
+	return chunks
+}
+
+// escapedSize returns the size of text once escaped, without the quotes.
+func escapedSize(text string) int {
+	return len(proto.QuoteText(text)) - 2
+}
+
 // TODO
 func (qb QueryBuilder) Decode(query string) QueryBuilder {
 	return qb
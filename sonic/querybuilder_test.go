@@ -0,0 +1,202 @@
+package sonic
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/uretgec/go-sonic/proto"
+)
+
+// chunkTexts returns the unquoted text argument of every chunk.
+func chunkTexts(t *testing.T, qb QueryBuilder, bufferSize int) []string {
+	t.Helper()
+
+	chunks, err := qb.EncodeChunks(bufferSize)
+	if err != nil {
+		t.Fatalf("EncodeChunks(%d): %v", bufferSize, err)
+	}
+
+	texts := make([]string, 0, len(chunks))
+	for _, args := range chunks {
+		if size := lineSize(args); size > bufferSize {
+			t.Errorf("chunk %q is %d bytes, buffer is %d", args, size, bufferSize)
+		}
+		// The text follows the collection, bucket and object arguments.
+		texts = append(texts, proto.UnquoteText(args[4]))
+	}
+	return texts
+}
+
+func TestEncodeChunksFits(t *testing.T) {
+	text := "first line\nsecond\tline  with \"quotes\""
+	qb := QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: text, Lang: "eng"}
+
+	size := lineSize(qb.Encode())
+	texts := chunkTexts(t, qb, size)
+	if len(texts) != 1 || texts[0] != text {
+		t.Fatalf("got %q, want the text unchanged", texts)
+	}
+
+	// One byte less and the line no longer fits.
+	if texts := chunkTexts(t, qb, size-1); len(texts) < 2 {
+		t.Fatalf("got %d chunk for a buffer one byte too small", len(texts))
+	}
+}
+
+func TestEncodeChunksKeepsSeparators(t *testing.T) {
+	text := strings.Repeat("alpha beta\ngamma\t\tdelta  epsilon\r\n", 20)
+	qb := QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: text}
+
+	for _, bufferSize := range []int{40, 41, 64, 100, 257} {
+		texts := chunkTexts(t, qb, bufferSize)
+		if len(texts) < 2 {
+			t.Fatalf("buffer %d: got %d chunk", bufferSize, len(texts))
+		}
+
+		var words []string
+		for _, chunk := range texts {
+			// Chunks are cut between words only, so each one is a piece
+			// of the original text with its separators.
+			if !strings.Contains(text, chunk) {
+				t.Errorf("buffer %d: chunk %q is not part of the text", bufferSize, chunk)
+			}
+			if strings.TrimSpace(chunk) != chunk {
+				t.Errorf("buffer %d: chunk %q starts or ends with a separator", bufferSize, chunk)
+			}
+			words = append(words, strings.Fields(chunk)...)
+		}
+		if got, want := strings.Join(words, " "), strings.Join(strings.Fields(text), " "); got != want {
+			t.Errorf("buffer %d: words changed:\n got %q\nwant %q", bufferSize, got, want)
+		}
+	}
+}
+
+func TestEncodeChunksLongWords(t *testing.T) {
+	word := strings.Repeat("çğıöşü", 20) // 2-byte runes
+	qb := QueryBuilder{Command: CmdIngestPop, Collection: "c", Bucket: "b", Object: "o", Text: "a " + word + " z"}
+
+	texts := chunkTexts(t, qb, 32)
+	var joined strings.Builder
+	for _, chunk := range texts {
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %q cuts a rune", chunk)
+		}
+		joined.WriteString(strings.ReplaceAll(chunk, " ", ""))
+	}
+	if got, want := joined.String(), "a"+word+"z"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeChunksBufferTooSmall(t *testing.T) {
+	qb := QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: "some text"}
+	if _, err := qb.EncodeChunks(16); err == nil {
+		t.Fatal("EncodeChunks succeeded with a buffer smaller than the command")
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		budget int
+		want   []string
+	}{
+		{"empty", "", 10, nil},
+		{"spaces only", " \t\n ", 10, nil},
+		{"fits", "hello world", 11, []string{"hello world"}},
+		{"keeps separators", "a\tb\nc  d", 5, []string{"a\tb", "c  d"}},
+		{"drops boundary separators", "  hello   world  ", 5, []string{"hello", "world"}},
+		{"long word", "abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"escaped quotes", `a"b"c`, 4, []string{`a"b`, `"c`}},
+		{"multibyte", "çğı", 4, []string{"çğ", "ı"}},
+		// Runes larger than the budget still move forward.
+		{"rune over budget", "é", 1, []string{"é"}},
+		{"runes over budget", "éa é", 1, []string{"é", "a", "é"}},
+		{"quote over budget", `"`, 1, []string{`"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitWords(tt.text, tt.budget)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitWords(%q, %d) = %q, want %q", tt.text, tt.budget, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPushContent(t *testing.T) {
+	client := NewClient(&Options{MaxBufferedSize: 1})
+	defer client.Close()
+
+	if got := client.SplitPushContent("é"); !reflect.DeepEqual(got, []string{"é"}) {
+		t.Fatalf("SplitPushContent = %q", got)
+	}
+}
+
+func TestQueryBuilderEncode(t *testing.T) {
+	tests := []struct {
+		qb   QueryBuilder
+		want string
+	}{
+		{QueryBuilder{Command: CmdSearchQuery, Collection: "c", Bucket: "b", Text: "hello"}, `QUERY c b "hello"`},
+		{QueryBuilder{Command: CmdSearchQuery, Collection: "c", Bucket: "b", Text: "say \"hi\"\r\n", Limit: 5, Offset: 10, Lang: "eng"}, `QUERY c b "say \"hi\"\r\n" LIMIT(5) OFFSET(10) LANG(eng)`},
+		// Zero LIMIT and OFFSET fall back to the Sonic defaults.
+		{QueryBuilder{Command: CmdSearchQuery, Collection: "c", Bucket: "b", Text: "hello", Limit: 0, Offset: 0}, `QUERY c b "hello"`},
+		{QueryBuilder{Command: CmdSearchSuggest, Collection: "c", Bucket: "b", Text: "hel", Limit: 3, Offset: 4, Lang: "eng"}, `SUGGEST c b "hel" LIMIT(3)`},
+		{QueryBuilder{Command: CmdSearchList, Collection: "c", Bucket: "b", Limit: 100, Offset: 200}, `LIST c b LIMIT(100) OFFSET(200)`},
+		{QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: "hello", Lang: "none"}, `PUSH c b o "hello" LANG(none)`},
+		{QueryBuilder{Command: CmdIngestPop, Collection: "c", Bucket: "b", Object: "o", Text: "hello"}, `POP c b o "hello"`},
+		{QueryBuilder{Command: CmdIngestCount, Collection: "c"}, `COUNT c`},
+		{QueryBuilder{Command: CmdIngestFlusho, Collection: "c", Bucket: "b", Object: "o"}, `FLUSHO c b o`},
+		{QueryBuilder{Command: CmdControlTrigger, Action: "consolidate"}, `TRIGGER consolidate`},
+		{QueryBuilder{Command: CmdSearchStart, Mode: ChannelSearch, Password: "secret"}, `START search secret`},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(tt.qb.Encode(), " "); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.qb, got, tt.want)
+		}
+	}
+}
+
+func TestQueryBuilderValidate(t *testing.T) {
+	valid := QueryBuilder{Command: CmdIngestPush, Collection: "c", Bucket: "b", Object: "o", Text: "hello", Lang: "eng"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(qb *QueryBuilder)
+	}{
+		{"no command", func(qb *QueryBuilder) { qb.Command = "" }},
+		{"no collection", func(qb *QueryBuilder) { qb.Collection = "" }},
+		{"no bucket", func(qb *QueryBuilder) { qb.Bucket = "" }},
+		{"no object", func(qb *QueryBuilder) { qb.Object = "" }},
+		{"no text", func(qb *QueryBuilder) { qb.Text = "" }},
+		{"blank text", func(qb *QueryBuilder) { qb.Text = " \t\n" }},
+		{"space in collection", func(qb *QueryBuilder) { qb.Collection = "my coll" }},
+		{"quote in bucket", func(qb *QueryBuilder) { qb.Bucket = `b"` }},
+		{"newline in object", func(qb *QueryBuilder) { qb.Object = "o\n" }},
+		{"object without bucket", func(qb *QueryBuilder) { qb.Command = CmdIngestCount; qb.Bucket = "" }},
+		{"negative limit", func(qb *QueryBuilder) { qb.Limit = -1 }},
+		{"limit too large", func(qb *QueryBuilder) { qb.Limit = MaxLimit + 1 }},
+		{"negative offset", func(qb *QueryBuilder) { qb.Offset = -1 }},
+		{"offset too large", func(qb *QueryBuilder) { qb.Offset = MaxOffset + 1 }},
+		{"unknown lang", func(qb *QueryBuilder) { qb.Lang = "xx" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := valid
+			tt.edit(&qb)
+			if err := qb.Validate(); !errors.Is(err, ErrQueryBuilder) {
+				t.Fatalf("got %v, want ErrQueryBuilder", err)
+			}
+		})
+	}
+}
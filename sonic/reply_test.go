@@ -0,0 +1,169 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/proto"
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+const transcriptStart = `
+< CONNECTED <sonic-server v1.4.0>
+> START search SecretPassword
+< STARTED search protocol(1) buffer(20000)
+`
+
+// newMockClient starts a MockServer playing transcriptStart followed by
+// script and returns a single connection search client for it.
+func newMockClient(t *testing.T, script string) (*Client, *sonictest.MockServer) {
+	t.Helper()
+	steps, err := sonictest.ParseTranscript(strings.NewReader(transcriptStart + script))
+	if err != nil {
+		t.Fatalf("ParseTranscript: %v", err)
+	}
+	mock := sonictest.NewMockServer(steps)
+	t.Cleanup(mock.Close)
+
+	client := NewClient(&Options{
+		Addr:         mock.Addr,
+		AuthPassword: "SecretPassword",
+		ChannelMode:  ChannelSearch,
+		PoolSize:     1,
+		MaxRetries:   -1,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	return client, mock
+}
+
+func TestQueryReplyEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    []string
+		wantErr string
+		is      error
+	}{
+		{
+			name: "event",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd obj:1 obj:2
+`,
+			want: []string{"obj:1", "obj:2"},
+		},
+		{
+			name: "empty event",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd
+`,
+			want: []string{},
+		},
+		{
+			name: "marker mismatch",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY xXxXxXxX obj:1
+`,
+			wantErr: "marker Bz9Bc4Kd not found",
+		},
+		{
+			name: "event of another command",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT SUGGEST Bz9Bc4Kd hello
+`,
+			wantErr: "marker Bz9Bc4Kd not found",
+		},
+		{
+			name: "ended instead of pending",
+			script: `
+> QUERY c b "hello"
+< ENDED quit
+`,
+			wantErr: "can't parse pending reply",
+		},
+		{
+			name: "ended instead of event",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< ENDED quit
+`,
+			wantErr: "marker Bz9Bc4Kd not found",
+		},
+		{
+			name: "dropped before event",
+			script: `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+! drop
+`,
+			wantErr: "EOF",
+		},
+		{
+			name: "error reply",
+			script: `
+> QUERY c b "hello"
+< ERR invalid_format(QUERY <collection> <bucket> "<terms>")
+`,
+			is: proto.ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mock := newMockClient(t, tt.script)
+
+			ids, err := client.Query(context.Background(), "c", "b", "hello", 0, 0, LangAutoDetect).Result()
+			switch {
+			case tt.is != nil:
+				if !errors.Is(err, tt.is) {
+					t.Fatalf("Query error = %v, want %v", err, tt.is)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Query error = %v, want %q", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Query: %v", err)
+				}
+				if strings.Join(ids, " ") != strings.Join(tt.want, " ") || len(ids) != len(tt.want) {
+					t.Fatalf("Query = %q, want %q", ids, tt.want)
+				}
+			}
+
+			_ = client.Close()
+			mock.Close()
+			if err := mock.Err(); err != nil {
+				t.Fatalf("mock: %v", err)
+			}
+		})
+	}
+}
+
+func TestMockUnexpectedCommand(t *testing.T) {
+	client, mock := newMockClient(t, `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd obj:1
+`)
+
+	err := client.Query(context.Background(), "c", "b", "other", 0, 0, LangAutoDetect).Err()
+	if code := new(proto.SonicError); !errors.As(err, code) || code.Code() != "unexpected_command" {
+		t.Fatalf("Query error = %v, want ERR unexpected_command", err)
+	}
+
+	mock.Close()
+	if err := mock.Err(); err == nil {
+		t.Fatal("mock reported no mismatch")
+	}
+}
@@ -0,0 +1,81 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+)
+
+// Resolver loads the documents of the object IDs returned by QUERY,
+// usually from the database the index was built from.
+type Resolver[T any] interface {
+	// Resolve returns the documents found for ids, by ID. IDs of deleted
+	// documents are left out of the map.
+	Resolve(ctx context.Context, ids []string) (map[string]T, error)
+}
+
+// ResolverFunc is an adapter to use a function as a Resolver.
+type ResolverFunc[T any] func(ctx context.Context, ids []string) (map[string]T, error)
+
+func (fn ResolverFunc[T]) Resolve(ctx context.Context, ids []string) (map[string]T, error) {
+	return fn(ctx, ids)
+}
+
+// SearchArgs are the arguments of SearchDocuments.
+type SearchArgs struct {
+	Collection string
+	Bucket     string
+	Terms      string
+	Limit      int
+	Offset     int
+	Lang       Lang
+
+	// Called with the IDs returned by QUERY that have no document anymore,
+	// before SearchDocuments returns. See FlushStaleObjects.
+	OnStale func(ctx context.Context, collection, bucket string, ids []string)
+}
+
+// SearchDocuments runs QUERY and loads the documents of the resulting IDs
+// with a single Resolve call. Documents are returned in Sonic's ranking
+// order; IDs without a document are dropped and passed to OnStale.
+func SearchDocuments[T any](ctx context.Context, c Cmdable, resolver Resolver[T], args *SearchArgs) ([]T, error) {
+	if args == nil {
+		return nil, errors.New("sonic: SearchDocuments requires args")
+	}
+
+	ids, err := c.Query(ctx, args.Collection, args.Bucket, args.Terms, args.Limit, args.Offset, args.Lang).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	found, err := resolver.Resolve(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]T, 0, len(ids))
+	var stale []string
+	for _, id := range ids {
+		if doc, ok := found[id]; ok {
+			docs = append(docs, doc)
+		} else {
+			stale = append(stale, id)
+		}
+	}
+
+	if len(stale) > 0 && args.OnStale != nil {
+		args.OnStale(ctx, args.Collection, args.Bucket, stale)
+	}
+
+	return docs, nil
+}
+
+// FlushStaleObjects returns an OnStale callback removing the stale objects
+// from the index with FLUSHO through an ingest client. Errors are ignored,
+// the objects are flushed again the next time they show up.
+func FlushStaleObjects(c IngestCmdable) func(ctx context.Context, collection, bucket string, ids []string) {
+	return func(ctx context.Context, collection, bucket string, ids []string) {
+		for _, id := range ids {
+			_ = c.FlushObject(ctx, collection, bucket, id).Err()
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type doc struct{ ID string }
+
+// docResolver resolves the IDs it holds, recording every call.
+type docResolver struct {
+	docs  map[string]doc
+	calls [][]string
+}
+
+func (r *docResolver) Resolve(ctx context.Context, ids []string) (map[string]doc, error) {
+	r.calls = append(r.calls, ids)
+	found := make(map[string]doc)
+	for _, id := range ids {
+		if d, ok := r.docs[id]; ok {
+			found[id] = d
+		}
+	}
+	return found, nil
+}
+
+func TestSearchDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     string
+		docs      []string
+		want      []doc
+		wantStale []string
+	}{
+		{
+			name:  "ranking order",
+			event: "obj:3 obj:1 obj:2",
+			docs:  []string{"obj:1", "obj:2", "obj:3"},
+			want:  []doc{{"obj:3"}, {"obj:1"}, {"obj:2"}},
+		},
+		{
+			name:      "stale ids",
+			event:     "obj:3 obj:1 obj:2 obj:4",
+			docs:      []string{"obj:1", "obj:3"},
+			want:      []doc{{"obj:3"}, {"obj:1"}},
+			wantStale: []string{"obj:2", "obj:4"},
+		},
+		{
+			name:      "all stale",
+			event:     "obj:1 obj:2",
+			want:      []doc{},
+			wantStale: []string{"obj:1", "obj:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, mock := newMockClient(t, `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd `+tt.event+`
+`)
+
+			resolver := &docResolver{docs: make(map[string]doc)}
+			for _, id := range tt.docs {
+				resolver.docs[id] = doc{id}
+			}
+
+			var stale []string
+			onStale := 0
+			docs, err := SearchDocuments[doc](ctx, client, resolver, &SearchArgs{
+				Collection: "c",
+				Bucket:     "b",
+				Terms:      "hello",
+				OnStale: func(ctx context.Context, collection, bucket string, ids []string) {
+					onStale++
+					if collection != "c" || bucket != "b" {
+						t.Errorf("OnStale(%q, %q), want c b", collection, bucket)
+					}
+					stale = ids
+				},
+			})
+			if err != nil {
+				t.Fatalf("SearchDocuments: %v", err)
+			}
+			if !reflect.DeepEqual(docs, tt.want) {
+				t.Fatalf("SearchDocuments = %v, want %v", docs, tt.want)
+			}
+
+			// A single Resolve call with every ID in ranking order.
+			if len(resolver.calls) != 1 || !reflect.DeepEqual(resolver.calls[0], strings.Fields(tt.event)) {
+				t.Fatalf("Resolve calls = %q, want one with %q", resolver.calls, tt.event)
+			}
+
+			if tt.wantStale == nil {
+				if onStale != 0 {
+					t.Fatalf("OnStale called with %q, want no call", stale)
+				}
+			} else if onStale != 1 || !reflect.DeepEqual(stale, tt.wantStale) {
+				t.Fatalf("OnStale called %d times with %q, want once with %q", onStale, stale, tt.wantStale)
+			}
+
+			_ = client.Close()
+			mock.Close()
+			if err := mock.Err(); err != nil {
+				t.Fatalf("mock: %v", err)
+			}
+		})
+	}
+}
+
+func TestSearchDocumentsNoResults(t *testing.T) {
+	client, _ := newMockClient(t, `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd
+`)
+
+	resolver := ResolverFunc[doc](func(ctx context.Context, ids []string) (map[string]doc, error) {
+		t.Errorf("Resolve(%q) called without results", ids)
+		return nil, nil
+	})
+	docs, err := SearchDocuments[doc](context.Background(), client, resolver, &SearchArgs{Collection: "c", Bucket: "b", Terms: "hello"})
+	if err != nil || len(docs) != 0 {
+		t.Fatalf("SearchDocuments = %v, %v, want no documents", docs, err)
+	}
+}
+
+func TestSearchDocumentsResolveError(t *testing.T) {
+	client, _ := newMockClient(t, `
+> QUERY c b "hello"
+< PENDING Bz9Bc4Kd
+< EVENT QUERY Bz9Bc4Kd obj:1
+`)
+
+	errResolve := errors.New("database down")
+	resolver := ResolverFunc[doc](func(ctx context.Context, ids []string) (map[string]doc, error) {
+		return nil, errResolve
+	})
+	args := &SearchArgs{
+		Collection: "c",
+		Bucket:     "b",
+		Terms:      "hello",
+		OnStale: func(ctx context.Context, collection, bucket string, ids []string) {
+			t.Errorf("OnStale(%q) called on a failed Resolve", ids)
+		},
+	}
+	if _, err := SearchDocuments[doc](context.Background(), client, resolver, args); err != errResolve {
+		t.Fatalf("SearchDocuments error = %v, want %v", err, errResolve)
+	}
+
+	if _, err := SearchDocuments[doc](context.Background(), client, resolver, nil); err == nil {
+		t.Fatal("SearchDocuments succeeded without args")
+	}
+}
+
+func TestFlushStaleObjects(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	for _, id := range []string{"msg:1", "msg:2"} {
+		if err := ingest.Push(ctx, "messages", "default", id, "hello", LangEng).Err(); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	// msg:2 was deleted from the database, but is still indexed.
+	resolver := ResolverFunc[doc](func(ctx context.Context, ids []string) (map[string]doc, error) {
+		return map[string]doc{"msg:1": {"msg:1"}}, nil
+	})
+	args := &SearchArgs{
+		Collection: "messages",
+		Bucket:     "default",
+		Terms:      "hello",
+		Lang:       LangEng,
+		OnStale:    FlushStaleObjects(ingest),
+	}
+	docs, err := SearchDocuments[doc](ctx, search, resolver, args)
+	if err != nil {
+		t.Fatalf("SearchDocuments: %v", err)
+	}
+	if want := []doc{{"msg:1"}}; !reflect.DeepEqual(docs, want) {
+		t.Fatalf("SearchDocuments = %v, want %v", docs, want)
+	}
+
+	if words := srv.Words("messages", "default", "msg:2"); len(words) != 0 {
+		t.Fatalf("msg:2 still indexed with %q", words)
+	}
+	if words := srv.Words("messages", "default", "msg:1"); len(words) == 0 {
+		t.Fatal("msg:1 was flushed")
+	}
+}
@@ -0,0 +1,558 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errRingShardsDown = errors.New("sonic: all ring shards are down")
+
+// Ring routing keys.
+const (
+	// Every bucket of a collection lives on the same shard.
+	ShardByCollection = "collection"
+	// Buckets of a collection are spread across shards.
+	ShardByBucket = "bucket"
+)
+
+// RingOptions are used to configure a ring client and should be
+// passed to NewRing.
+type RingOptions struct {
+	// Settings of every shard client. Addr is replaced by the shard address.
+	Options
+
+	// Map of name => host:port addresses of ring shards.
+	Addrs map[string]string
+
+	// NewClient creates a shard client with provided name and options.
+	NewClient func(name string, opt *Options) *Client
+
+	// Routing key of the commands, ShardByCollection or ShardByBucket.
+	// Default is ShardByCollection.
+	ShardBy string
+
+	// Number of points every shard gets on the hash ring.
+	// Default is 100.
+	HashReplicas int
+
+	// Frequency of PING commands sent to check shards availability.
+	// Shard is considered down after 3 subsequent failed checks.
+	// Default is 500 milliseconds.
+	HeartbeatFrequency time.Duration
+}
+
+func (opt *RingOptions) init() {
+	if opt.NewClient == nil {
+		opt.NewClient = func(name string, opt *Options) *Client {
+			return NewClient(opt)
+		}
+	}
+	if opt.ShardBy == "" {
+		opt.ShardBy = ShardByCollection
+	}
+	if opt.HashReplicas == 0 {
+		opt.HashReplicas = 100
+	}
+	if opt.HeartbeatFrequency == 0 {
+		opt.HeartbeatFrequency = 500 * time.Millisecond
+	}
+}
+
+func (opt *RingOptions) clientOptions(addr string) *Options {
+	o := opt.Options.clone()
+	o.Addr = addr
+	return o
+}
+
+// key returns the routing key of a command, or false if the command does
+// not carry enough arguments to be routed to a single shard.
+func (opt *RingOptions) key(cmd Cmder) (string, bool) {
+	switch commandChannel(cmd.Name()) {
+	case ChannelSearch, ChannelIngest:
+	default:
+		return "", false
+	}
+
+	args := cmd.Args()
+	if len(args) < 2 {
+		return "", false
+	}
+	if opt.ShardBy != ShardByBucket {
+		return args[1], true
+	}
+
+	if len(args) < 3 {
+		return "", false
+	}
+	return args[1] + "/" + args[2], true
+}
+
+//------------------------------------------------------------------------------
+
+// consistentHash maps keys to shard names, moving as few keys as possible
+// when shards are added or removed.
+type consistentHash struct {
+	points []uint32
+	names  map[uint32]string
+}
+
+func newConsistentHash(replicas int, names []string) *consistentHash {
+	h := &consistentHash{
+		names: make(map[uint32]string, replicas*len(names)),
+	}
+	for _, name := range names {
+		for i := 0; i < replicas; i++ {
+			point := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + name))
+			h.points = append(h.points, point)
+			h.names[point] = name
+		}
+	}
+	sort.Slice(h.points, func(i, j int) bool { return h.points[i] < h.points[j] })
+	return h
+}
+
+func (h *consistentHash) Get(key string) string {
+	if len(h.points) == 0 {
+		return ""
+	}
+
+	point := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(h.points), func(i int) bool { return h.points[i] >= point })
+	if idx == len(h.points) {
+		idx = 0
+	}
+	return h.names[h.points[idx]]
+}
+
+//------------------------------------------------------------------------------
+
+// nodeHealth tracks whether a server answers PING.
+type nodeHealth struct {
+	down int32 // atomic
+}
+
+func (h *nodeHealth) IsDown() bool {
+	const threshold = 3
+	return atomic.LoadInt32(&h.down) >= threshold
+}
+
+func (h *nodeHealth) IsUp() bool {
+	return !h.IsDown()
+}
+
+// Vote votes to set node state and returns true if state was changed.
+func (h *nodeHealth) Vote(up bool) bool {
+	if up {
+		changed := h.IsDown()
+		atomic.StoreInt32(&h.down, 0)
+		return changed
+	}
+
+	if h.IsDown() {
+		return false
+	}
+
+	atomic.AddInt32(&h.down, 1)
+	return h.IsDown()
+}
+
+type ringShard struct {
+	nodeHealth
+
+	Client *Client
+}
+
+func (shard *ringShard) String() string {
+	var state string
+	if shard.IsUp() {
+		state = "up"
+	} else {
+		state = "down"
+	}
+	return fmt.Sprintf("%s is %s", shard.Client.Options().Addr, state)
+}
+
+//------------------------------------------------------------------------------
+
+// Ring is a Sonic client that uses consistent hashing to distribute
+// collections (or buckets) across multiple Sonic servers (shards).
+// It's safe for concurrent use by multiple goroutines.
+//
+// Ring monitors the state of each shard with PING and removes dead shards
+// from the hash ring. When a shard comes online it is added back. This
+// moves keys between shards, so Ring is meant for deployments where every
+// key can be re-ingested or where shards rarely go down.
+type Ring struct {
+	baseCmdable
+	cmdable
+	ingestCmdable
+
+	opt *RingOptions
+
+	mu     sync.RWMutex
+	shards map[string]*ringShard
+	hash   *consistentHash
+	closed bool
+
+	closeCh chan struct{}
+}
+
+var (
+	_ Cmdable       = (*Ring)(nil)
+	_ IngestCmdable = (*Ring)(nil)
+)
+
+func NewRing(opt *RingOptions) *Ring {
+	opt.init()
+
+	ring := Ring{
+		opt:     opt,
+		shards:  make(map[string]*ringShard),
+		closeCh: make(chan struct{}),
+	}
+	ring.baseCmdable = ring.Process
+	ring.cmdable = ring.Process
+	ring.ingestCmdable = ring.Process
+
+	ring.SetAddrs(opt.Addrs)
+
+	go ring.heartbeat()
+
+	return &ring
+}
+
+// SetAddrs replaces the shards of the ring with addrs, a map of
+// name => host:port. Shards whose address did not change are kept.
+func (c *Ring) SetAddrs(addrs map[string]string) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+
+	var unused []*ringShard
+	shards := make(map[string]*ringShard, len(addrs))
+	for name, addr := range addrs {
+		if shard, ok := c.shards[name]; ok && shard.Client.Options().Addr == addr {
+			shards[name] = shard
+			continue
+		}
+		shards[name] = &ringShard{
+			Client: c.opt.NewClient(name, c.opt.clientOptions(addr)),
+		}
+	}
+	for name, shard := range c.shards {
+		if shards[name] != shard {
+			unused = append(unused, shard)
+		}
+	}
+
+	c.shards = shards
+	c.rebalanceLocked()
+	c.mu.Unlock()
+
+	for _, shard := range unused {
+		_ = shard.Client.Close()
+	}
+}
+
+// AddShard adds or replaces a single shard.
+func (c *Ring) AddShard(name, addr string) {
+	c.SetAddrs(c.addrsWith(func(addrs map[string]string) {
+		addrs[name] = addr
+	}))
+}
+
+// RemoveShard removes a single shard and closes its client.
+func (c *Ring) RemoveShard(name string) {
+	c.SetAddrs(c.addrsWith(func(addrs map[string]string) {
+		delete(addrs, name)
+	}))
+}
+
+func (c *Ring) addrsWith(fn func(map[string]string)) map[string]string {
+	c.mu.RLock()
+	addrs := make(map[string]string, len(c.shards))
+	for name, shard := range c.shards {
+		addrs[name] = shard.Client.Options().Addr
+	}
+	c.mu.RUnlock()
+
+	fn(addrs)
+	return addrs
+}
+
+// rebalanceLocked rebuilds the hash ring from the live shards.
+func (c *Ring) rebalanceLocked() {
+	live := make([]string, 0, len(c.shards))
+	for name, shard := range c.shards {
+		if shard.IsUp() {
+			live = append(live, name)
+		}
+	}
+	sort.Strings(live)
+	c.hash = newConsistentHash(c.opt.HashReplicas, live)
+}
+
+// heartbeat monitors state of each shard in the ring.
+func (c *Ring) heartbeat() {
+	ticker := time.NewTicker(c.opt.HeartbeatFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closeCh:
+			return
+		}
+
+		var rebalance bool
+		for _, shard := range c.liveAndDeadShards() {
+			ctx, cancel := context.WithTimeout(context.Background(), c.opt.HeartbeatFrequency)
+			err := shard.Client.Ping(ctx).Err()
+			cancel()
+
+			if shard.Vote(err == nil) {
+				rebalance = true
+			}
+		}
+
+		if rebalance {
+			c.mu.Lock()
+			c.rebalanceLocked()
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Ring) liveAndDeadShards() []*ringShard {
+	c.mu.RLock()
+	shards := make([]*ringShard, 0, len(c.shards))
+	for _, shard := range c.shards {
+		shards = append(shards, shard)
+	}
+	c.mu.RUnlock()
+	return shards
+}
+
+func (c *Ring) liveShards() ([]*Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	var clients []*Client
+	for _, shard := range c.shards {
+		if shard.IsUp() {
+			clients = append(clients, shard.Client)
+		}
+	}
+	if len(clients) == 0 {
+		return nil, errRingShardsDown
+	}
+	return clients, nil
+}
+
+// ShardByKey returns the shard client a routing key is mapped to.
+func (c *Ring) ShardByKey(key string) (*Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	name := c.hash.Get(key)
+	if name == "" {
+		return nil, errRingShardsDown
+	}
+	return c.shards[name].Client, nil
+}
+
+// Len returns the current number of live shards in the ring.
+func (c *Ring) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var n int
+	for _, shard := range c.shards {
+		if shard.IsUp() {
+			n++
+		}
+	}
+	return n
+}
+
+// ForEachShard concurrently calls the fn on each live shard in the ring.
+// It returns the first error if any.
+func (c *Ring) ForEachShard(ctx context.Context, fn func(ctx context.Context, client *Client) error) error {
+	clients, err := c.liveShards()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			if err := fn(ctx, client); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}(client)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Process routes the cmd to the shard of its key. Commands without a
+// complete key, like FLUSHC when sharding by bucket or PING, are sent to
+// every live shard and their results are summed.
+func (c *Ring) Process(ctx context.Context, cmd Cmder) error {
+	retErr := c.process(ctx, cmd)
+	cmd.SetErr(retErr)
+	return retErr
+}
+
+func (c *Ring) process(ctx context.Context, cmd Cmder) error {
+	if key, ok := c.opt.key(cmd); ok {
+		client, err := c.ShardByKey(key)
+		if err != nil {
+			return err
+		}
+		return client.Process(ctx, cmd)
+	}
+
+	switch cmd := cmd.(type) {
+	case *IntCmd:
+		var mu sync.Mutex
+		var sum int64
+		err := c.ForEachShard(ctx, func(ctx context.Context, client *Client) error {
+			shardCmd := NewIntCmd(ctx, cmd.Args()...)
+			if err := client.Process(ctx, shardCmd); err != nil {
+				return err
+			}
+			mu.Lock()
+			sum += shardCmd.Val()
+			mu.Unlock()
+			return nil
+		})
+		cmd.SetVal(sum)
+		return err
+	case *StatusCmd:
+		return c.ForEachShard(ctx, func(ctx context.Context, client *Client) error {
+			shardCmd := NewStatusCmd(ctx, cmd.Args()...)
+			err := client.Process(ctx, shardCmd)
+			if err == nil {
+				cmd.SetVal(shardCmd.Val())
+			}
+			return err
+		})
+	}
+
+	return fmt.Errorf("sonic: ring can't route %s without a key", cmd.Name())
+}
+
+// MPush groups the items by shard and pushes every group with the
+// shard's MPush, reporting the outcome of each item in order.
+func (c *Ring) MPush(ctx context.Context, items []IngestItem) *BatchCmd {
+	return c.processBatch(ctx, items, (*Client).MPush)
+}
+
+// MPop groups the items by shard like MPush.
+func (c *Ring) MPop(ctx context.Context, items []IngestItem) *BatchCmd {
+	return c.processBatch(ctx, items, (*Client).MPop)
+}
+
+func (c *Ring) processBatch(
+	ctx context.Context, items []IngestItem,
+	fn func(*Client, context.Context, []IngestItem) *BatchCmd,
+) *BatchCmd {
+	cmd := newBatchCmd(ctx, items)
+
+	type group struct {
+		idx   []int
+		items []IngestItem
+	}
+	groups := make(map[*Client]*group)
+	for i, item := range items {
+		key := item.Collection
+		if c.opt.ShardBy == ShardByBucket {
+			key += "/" + item.Bucket
+		}
+
+		client, err := c.ShardByKey(key)
+		if err != nil {
+			cmd.val[i].Err = err
+			continue
+		}
+
+		g, ok := groups[client]
+		if !ok {
+			g = &group{}
+			groups[client] = g
+		}
+		g.idx = append(g.idx, i)
+		g.items = append(g.items, item)
+	}
+
+	var wg sync.WaitGroup
+	for client, g := range groups {
+		wg.Add(1)
+		go func(client *Client, g *group) {
+			defer wg.Done()
+			for i, res := range fn(client, ctx, g.items).Val() {
+				cmd.val[g.idx[i]] = res
+			}
+		}(client, g)
+	}
+	wg.Wait()
+
+	cmd.setBatchErr()
+	return cmd
+}
+
+// Close closes the ring client, releasing any open resources.
+//
+// It is rare to Close a Ring, as the Ring is meant to be long-lived
+// and shared between many goroutines.
+func (c *Ring) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.closed = true
+	close(c.closeCh)
+
+	var firstErr error
+	for _, shard := range c.shards {
+		if err := shard.Client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.shards = nil
+	c.hash = newConsistentHash(c.opt.HashReplicas, nil)
+
+	return firstErr
+}
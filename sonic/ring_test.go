@@ -0,0 +1,129 @@
+package sonic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestConsistentHashDistribution(t *testing.T) {
+	const keys = 3000
+	names := []string{"a", "b", "c"}
+	h := newConsistentHash(100, names)
+
+	before := make(map[string]string, keys)
+	counts := make(map[string]int)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("collection:%d", i)
+		name := h.Get(key)
+		before[key] = name
+		counts[name]++
+	}
+	for _, name := range names {
+		// A perfect spread is a third of the keys each.
+		if n := counts[name]; n < keys/5 || n > keys/2 {
+			t.Errorf("shard %s got %d of %d keys", name, n, keys)
+		}
+	}
+
+	// Removing a shard only moves its own keys.
+	h = newConsistentHash(100, []string{"a", "c"})
+	for key, name := range before {
+		if got := h.Get(key); name != "b" && got != name {
+			t.Fatalf("key %s moved from %s to %s", key, name, got)
+		}
+	}
+
+	// Adding a shard only moves keys to it.
+	h = newConsistentHash(100, []string{"a", "b", "c", "d"})
+	var moved int
+	for key, name := range before {
+		if got := h.Get(key); got != name {
+			if got != "d" {
+				t.Fatalf("key %s moved from %s to %s", key, name, got)
+			}
+			moved++
+		}
+	}
+	if moved < keys/8 || moved > keys/2 {
+		t.Errorf("%d of %d keys moved to the new shard", moved, keys)
+	}
+}
+
+func TestRing(t *testing.T) {
+	ctx := context.Background()
+	srvs := map[string]string{}
+	for _, name := range []string{"a", "b", "c"} {
+		srvs[name] = newTestServer(t).Addr
+	}
+
+	ring := NewRing(&RingOptions{
+		Options: Options{ChannelMode: ChannelIngest},
+		Addrs:   srvs,
+	})
+	defer ring.Close()
+
+	const n = 30
+	shards := make(map[string]*Client)
+	for i := 0; i < n; i++ {
+		collection := fmt.Sprintf("collection:%d", i)
+		if err := ring.Push(ctx, collection, "b", "o", "hello", LangNone).Err(); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+		client, err := ring.ShardByKey(collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards[collection] = client
+		if count, err := client.Count(ctx, collection, "b", "").Result(); err != nil || count != 1 {
+			t.Fatalf("%s: COUNT on its shard = %d, %v", collection, count, err)
+		}
+	}
+
+	// FLUSHC has a key and goes to a single shard.
+	if flushed, err := ring.FlushCollection(ctx, "collection:0").Result(); err != nil || flushed != 1 {
+		t.Fatalf("FlushCollection = %d, %v", flushed, err)
+	}
+
+	// Removing a shard closes its client and moves only its keys.
+	ring.SetAddrs(map[string]string{"a": srvs["a"], "c": srvs["c"]})
+	if n := ring.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+	var moved int
+	for collection, before := range shards {
+		after, err := ring.ShardByKey(collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if before.Options().Addr == srvs["b"] {
+			if after.Options().Addr == srvs["b"] {
+				t.Fatalf("%s still routed to the removed shard", collection)
+			}
+			if err := before.Ping(ctx).Err(); err != ErrClosed {
+				t.Fatalf("Ping on the removed shard = %v, want %v", err, ErrClosed)
+			}
+			moved++
+			continue
+		}
+		// Kept shards keep their client.
+		if after != before {
+			t.Fatalf("%s moved from %s to %s", collection, before.Options().Addr, after.Options().Addr)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no collection was routed to the removed shard")
+	}
+
+	// Adding it back restores the original routing.
+	ring.AddShard("b", srvs["b"])
+	for collection, before := range shards {
+		after, err := ring.ShardByKey(collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.Options().Addr != before.Options().Addr {
+			t.Fatalf("%s routed to %s, want %s", collection, after.Options().Addr, before.Options().Addr)
+		}
+	}
+}
@@ -4,10 +4,9 @@ package sonic
 import (
 	"context"
 	"errors"
-	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
-	"unicode/utf8"
 
 	"github.com/uretgec/go-sonic/pool"
 	"github.com/uretgec/go-sonic/proto"
@@ -17,13 +16,17 @@ type baseClient struct {
 	opt      *Options
 	connPool pool.Pooler
 
+	// Buffer size last announced by the server, shared by clones.
+	announced *int64 // atomic
+
 	onClose func() error
 }
 
 func newBaseClient(opt *Options, connPool pool.Pooler) *baseClient {
 	return &baseClient{
-		opt:      opt,
-		connPool: connPool,
+		opt:       opt,
+		connPool:  connPool,
+		announced: new(int64),
 	}
 }
 
@@ -95,26 +98,27 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 	}
 	cn.Inited = true
 
-	if c.opt.AuthPassword == "" && !c.opt.readOnly && c.opt.OnConnect == nil {
-		return nil
-	}
-
 	connPool := pool.NewSingleConnPool(c.connPool, cn)
 	conn := newConn(ctx, c.opt, connPool)
 
 	// Connect Sonic Server First Time
-	bufferSize, err := conn.Start(ctx, c.opt.ChannelMode, c.opt.AuthPassword).Int()
+	started, err := conn.Start(ctx, c.opt.ChannelMode, c.opt.AuthPassword).Result()
 	if err != nil {
-		bufferSize, err = conn.Start(ctx, c.opt.ChannelMode, c.opt.AuthPassword).Int()
+		// The server closes the connection after rejecting START.
+		if isSonicError(err) {
+			return err
+		}
+		started, err = conn.Start(ctx, c.opt.ChannelMode, c.opt.AuthPassword).Result()
 		if err != nil {
 			return err
 		}
 		//return err
 	}
 
-	// Set MaxBufferedSize
-	c.opt.MaxBufferedSize = bufferSize
-	fmt.Printf("INIT CONN %#v\n", c.connPool.Stats())
+	cn.BufferSize = started.Buffer
+	if c.announced != nil {
+		atomic.StoreInt64(c.announced, int64(started.Buffer))
+	}
 	if c.opt.OnConnect != nil {
 		return c.opt.OnConnect(ctx, conn)
 	}
@@ -163,6 +167,16 @@ func (c *baseClient) withConn(ctx context.Context, fn func(context.Context, *poo
 }
 
 func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
+	if c.opt.LangDetector != nil {
+		detectLang(c.opt.LangDetector, cmd)
+	}
+	if c.opt.QueryCache != nil {
+		return c.opt.QueryCache.process(ctx, cmd, c.processRetry)
+	}
+	return c.processRetry(ctx, cmd)
+}
+
+func (c *baseClient) processRetry(ctx context.Context, cmd Cmder) error {
 	var lastErr error
 	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
 		attempt := attempt
@@ -187,7 +201,7 @@ func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool
 	retryTimeout := uint32(1)
 	err := c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
 		err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
-			return writeCmd(wr, cmd)
+			return writeCmd(wr, cmd, c.bufferSize(cn))
 		})
 		if err != nil {
 			return err
@@ -211,6 +225,26 @@ func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool
 	return retry, err
 }
 
+// bufferSize returns the command line size negotiated on cn, or
+// Options.MaxBufferedSize if the connection did not negotiate one.
+func (c *baseClient) bufferSize(cn *pool.Conn) int {
+	if cn.BufferSize > 0 {
+		return cn.BufferSize
+	}
+	return c.opt.MaxBufferedSize
+}
+
+// maxBufferedSize returns the buffer size last announced by the server, or
+// Options.MaxBufferedSize until a connection is started.
+func (c *baseClient) maxBufferedSize() int {
+	if c.announced != nil {
+		if n := atomic.LoadInt64(c.announced); n > 0 {
+			return int(n)
+		}
+	}
+	return c.opt.MaxBufferedSize
+}
+
 func (c *baseClient) retryBackoff(attempt int) time.Duration {
 	return RetryBackoff(attempt, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff)
 }
@@ -270,22 +304,23 @@ func NewClient(opt *Options) *Client {
 		baseClient: newBaseClient(opt, newConnPool(opt)),
 		ctx:        context.Background(),
 	}
-	if opt.ChannelMode == ChannelSearch {
-		c.cmdable = c.Process
-	} else if opt.ChannelMode == ChannelIngest {
-		c.ingestCmdable = c.Process
-	} else if opt.ChannelMode == ChannelControl {
-		c.controlCmdable = c.Process
-	}
-
-	c.baseCmdable = c.Process
+	c.init()
 
 	return &c
 }
 
+// init binds every command set to Process, which rejects commands of
+// other channels with ErrWrongChannel.
+func (c *Client) init() {
+	c.baseCmdable = c.Process
+	c.cmdable = c.Process
+	c.ingestCmdable = c.Process
+	c.controlCmdable = c.Process
+}
+
 func (c *Client) clone() *Client {
 	clone := *c
-	clone.cmdable = clone.Process
+	clone.init()
 	return &clone
 }
 
@@ -320,54 +355,81 @@ func (c *Client) Do(ctx context.Context, args ...string) *Cmd {
 }
 
 func (c *Client) Process(ctx context.Context, cmd Cmder) error {
+	if err := CheckChannel(cmd.Name(), c.opt.ChannelMode); err != nil {
+		cmd.SetErr(err)
+		return err
+	}
+
 	retErr := c.baseClient.process(ctx, cmd)
 	cmd.SetErr(retErr)
 	return retErr
 }
 
+// Pipelined queues the ingest commands run by fn and executes them
+// with a single round trip.
+func (c *Client) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return c.Pipeline().Pipelined(ctx, fn)
+}
+
+// Pipeline returns a Pipeliner that queues commands until Exec is called.
+func (c *Client) Pipeline() Pipeliner {
+	pipe := Pipeline{
+		ctx:  c.ctx,
+		exec: c.processPipeline,
+	}
+	pipe.init()
+	return &pipe
+}
+
 // Options returns read-only Options that were used to create the client.
 func (c *Client) Options() *Options {
 	return c.opt
 }
 
-// Check push content is too big for buffered size
-func (c *Client) IsPushContentReady(str string) bool {
-	str = sanitize(str)
-	return utf8.RuneCountInString(str) >= c.opt.MaxBufferedSize
+// MPush pushes every item, splitting its text by the negotiated buffer size
+// like Push.
+// Items are spread across pooled connections, at most Options.BatchConcurrency
+// at a time, and the outcome of each item is reported separately.
+func (c *Client) MPush(ctx context.Context, items []IngestItem) *BatchCmd {
+	return processBatch(ctx, c.Process, CmdIngestPush, items, c.opt.BatchConcurrency)
 }
 
-// Chunks content
-func (c *Client) SplitPushContent(str string) []string {
-	var splits []string
+// MPop pops every item the same way MPush pushes them.
+func (c *Client) MPop(ctx context.Context, items []IngestItem) *BatchCmd {
+	return processBatch(ctx, c.Process, CmdIngestPop, items, c.opt.BatchConcurrency)
+}
 
-	str = sanitize(str)
-	limitter := c.opt.MaxBufferedSize
+// Check push content is too big for the buffer announced by the server,
+// or Options.MaxBufferedSize before the first connection.
+//
+// Deprecated: Push and Pop split text too big for the connection buffer.
+func (c *Client) IsPushContentReady(str string) bool {
+	return len(proto.QuoteText(str)) >= c.maxBufferedSize()
+}
 
-	var l, r int
-	for l, r = 0, limitter; r < len(str); l, r = r, r+limitter {
-		for !utf8.RuneStart(str[r]) {
-			r--
-		}
-		splits = append(splits, str[l:r])
-	}
-	splits = append(splits, str[l:])
-	return splits
+// Chunks content on word boundaries so every escaped chunk fits in the
+// buffer announced by the server, or Options.MaxBufferedSize before the
+// first connection.
+//
+// Deprecated: Push and Pop split text too big for the connection buffer,
+// taking the command itself into account.
+func (c *Client) SplitPushContent(str string) []string {
+	return splitWords(str, c.maxBufferedSize())
 }
 
 // Suggest word checker
 // Suggest only one word, not multiple
+//
+// Deprecated: SuggestPhrase completes the last word of a phrase.
 func (c *Client) IsSuggestWordReady(words string) bool {
-	index := findDummyIndex(words)
-	return index == -1
+	return strings.TrimSpace(words) == firstWord(words)
 }
 
+// Returns the first word of words, the only one Suggest can complete.
+//
+// Deprecated: SuggestPhrase completes the last word of a phrase.
 func (c *Client) GetSuggestWord(words string) string {
-	index := findDummyIndex(words)
-	if index != -1 {
-		return words
-	}
-
-	return truncateText(words, index)
+	return firstWord(words)
 }
 
 // Pool Stats
@@ -379,6 +441,14 @@ func (c *Client) PoolStats() *PoolStats {
 	return (*PoolStats)(stats)
 }
 
+// CacheStats returns query cache stats, nil without Options.QueryCache.
+func (c *Client) CacheStats() *CacheStats {
+	if c.opt.QueryCache == nil {
+		return nil
+	}
+	return c.opt.QueryCache.Stats()
+}
+
 //------------------------------------------------------------------------------
 
 type conn struct {
@@ -408,20 +478,50 @@ func newConn(ctx context.Context, opt *Options, connPool pool.Pooler) *Conn {
 		},
 		ctx: ctx,
 	}
-	if opt.ChannelMode == ChannelSearch {
-		c.cmdable = c.Process
-	} else if opt.ChannelMode == ChannelIngest {
-		c.ingestCmdable = c.Process
-	} else if opt.ChannelMode == ChannelControl {
-		c.controlCmdable = c.Process
-	}
-
 	c.baseCmdable = c.Process
-	c.statefulCmdable = c.Process
+	c.cmdable = c.Process
+	c.ingestCmdable = c.Process
+	c.controlCmdable = c.Process
+	c.statefulCmdable = c.processStateful
 	return &c
 }
 
+// processStateful runs commands sent before a channel is selected, like START.
+func (c *Conn) processStateful(ctx context.Context, cmd Cmder) error {
+	retErr := c.baseClient.process(ctx, cmd)
+	cmd.SetErr(retErr)
+	return retErr
+}
+
+func (c *Conn) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return c.Pipeline().Pipelined(ctx, fn)
+}
+
+func (c *Conn) Pipeline() Pipeliner {
+	pipe := Pipeline{
+		ctx:  c.ctx,
+		exec: c.processPipeline,
+	}
+	pipe.init()
+	return &pipe
+}
+
+// MPush pushes every item one after another on the single connection.
+func (c *Conn) MPush(ctx context.Context, items []IngestItem) *BatchCmd {
+	return processBatch(ctx, c.Process, CmdIngestPush, items, 1)
+}
+
+// MPop pops every item one after another on the single connection.
+func (c *Conn) MPop(ctx context.Context, items []IngestItem) *BatchCmd {
+	return processBatch(ctx, c.Process, CmdIngestPop, items, 1)
+}
+
 func (c *Conn) Process(ctx context.Context, cmd Cmder) error {
+	if err := CheckChannel(cmd.Name(), c.opt.ChannelMode); err != nil {
+		cmd.SetErr(err)
+		return err
+	}
+
 	retErr := c.baseClient.process(ctx, cmd)
 	cmd.SetErr(retErr)
 	return retErr
@@ -0,0 +1,109 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+func newTestServer(t *testing.T) *sonictest.Server {
+	t.Helper()
+	srv := sonictest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, addr, channelMode string) *Client {
+	t.Helper()
+	client := NewClient(&Options{
+		Addr:        addr,
+		ChannelMode: channelMode,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClientWithoutPassword(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	if err := ingest.Push(ctx, "messages", "default", "msg:1", "hello world", LangEng).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if err := ingest.Push(ctx, "messages", "default", "msg:2", "hello there", LangEng).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	ids, err := search.Query(ctx, "messages", "default", "hello", 10, 0, LangEng).Result()
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if want := []string{"msg:2", "msg:1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Query = %q, want %q", ids, want)
+	}
+
+	words, err := search.Suggest(ctx, "messages", "default", "th", 5).Result()
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if want := []string{"there"}; !reflect.DeepEqual(words, want) {
+		t.Fatalf("Suggest = %q, want %q", words, want)
+	}
+
+	n, err := ingest.Count(ctx, "messages", "default", "msg:1").Result()
+	if err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v, want 2", n, err)
+	}
+}
+
+func TestClientWrongPassword(t *testing.T) {
+	srv := sonictest.NewUnstartedServer()
+	srv.Password = "secret"
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	client := NewClient(&Options{
+		Addr:         srv.Addr,
+		AuthPassword: "wrong",
+		ChannelMode:  ChannelSearch,
+		MaxRetries:   -1,
+	})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want %v", err, ErrAuthFailed)
+	}
+}
+
+func TestClientAnnouncedBufferSize(t *testing.T) {
+	srv := sonictest.NewUnstartedServer()
+	srv.BufferSize = 64
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	ingest := NewClient(&Options{Addr: srv.Addr, ChannelMode: ChannelIngest, MaxBufferedSize: 1000})
+	defer ingest.Close()
+
+	text := strings.Repeat("word ", 20)
+	// Options.MaxBufferedSize is used until the server announces its buffer.
+	if ingest.IsPushContentReady(text) {
+		t.Fatal("IsPushContentReady = true before the first connection")
+	}
+	if err := ingest.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if !ingest.IsPushContentReady(text) {
+		t.Fatal("IsPushContentReady = false with the announced buffer")
+	}
+	for _, chunk := range ingest.SplitPushContent(text) {
+		if len(chunk) > srv.BufferSize {
+			t.Fatalf("chunk of %d bytes, buffer is %d", len(chunk), srv.BufferSize)
+		}
+	}
+}
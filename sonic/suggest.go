@@ -0,0 +1,83 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SuggestArgs are the arguments of SuggestPhrase.
+type SuggestArgs struct {
+	Collection string
+	Bucket     string
+	Phrase     string
+	Limit      int
+
+	// Runs QUERY on every completed phrase and drops the ones without hits,
+	// e.g. when the completed word never appears with the leading words.
+	CheckHits bool
+	// Locale of the QUERY commands sent for CheckHits.
+	Lang Lang
+}
+
+// SuggestPhrase completes the last word of a phrase typed in a search box.
+// The trailing partial word is completed with SUGGEST, which only handles a
+// single word, and the leading words are kept as typed. Nothing is
+// suggested when the phrase ends with a separator.
+func SuggestPhrase(ctx context.Context, c Cmdable, args *SuggestArgs) ([]string, error) {
+	if args == nil {
+		return nil, errors.New("sonic: SuggestPhrase requires args")
+	}
+
+	leading, word := splitLastWord(args.Phrase)
+	if word == "" {
+		return nil, nil
+	}
+
+	words, err := c.Suggest(ctx, args.Collection, args.Bucket, word, args.Limit).Result()
+	if err != nil || len(words) == 0 {
+		return nil, err
+	}
+
+	phrases := make([]string, 0, len(words))
+	for _, w := range words {
+		phrase := leading + w
+		if args.CheckHits {
+			ids, err := c.Query(ctx, args.Collection, args.Bucket, phrase, 1, 0, args.Lang).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+		}
+		phrases = append(phrases, phrase)
+	}
+
+	return phrases, nil
+}
+
+// splitLastWord splits s before its trailing word, made of letters and
+// digits. The word is empty if s ends with any other character.
+func splitLastWord(s string) (leading, word string) {
+	i := len(s)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		i -= size
+	}
+	return s[:i], s[i:]
+}
+
+// firstWord returns the leading word of s, ignoring leading spaces.
+func firstWord(s string) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i != -1 {
+		return s[:i]
+	}
+	return s
+}
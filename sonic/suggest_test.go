@@ -0,0 +1,163 @@
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitLastWord(t *testing.T) {
+	tests := []struct {
+		in, leading, word string
+	}{
+		{"", "", ""},
+		{"wor", "", "wor"},
+		{"hello wor", "hello ", "wor"},
+		{"Hello,  wor", "Hello,  ", "wor"},
+		{"hello\twor", "hello\t", "wor"},
+		{"hello ", "hello ", ""},
+		{"hello!", "hello!", ""},
+		{"café crè", "café ", "crè"},
+		{"route 66", "route ", "66"},
+	}
+
+	for _, tt := range tests {
+		leading, word := splitLastWord(tt.in)
+		if leading != tt.leading || word != tt.word {
+			t.Errorf("splitLastWord(%q) = %q, %q, want %q, %q", tt.in, leading, word, tt.leading, tt.word)
+		}
+	}
+}
+
+func TestSuggestPhrase(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	ingest := newTestClient(t, srv.Addr, ChannelIngest)
+	search := newTestClient(t, srv.Addr, ChannelSearch)
+
+	for id, text := range map[string]string{
+		"msg:1": "hello world",
+		"msg:2": "goodbye work",
+		"msg:3": "hello worm",
+	} {
+		if err := ingest.Push(ctx, "messages", "default", id, text, LangEng).Err(); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args SuggestArgs
+		want []string
+	}{
+		{
+			name: "single word",
+			args: SuggestArgs{Phrase: "wor"},
+			want: []string{"work", "world", "worm"},
+		},
+		{
+			name: "last word only",
+			args: SuggestArgs{Phrase: "hello wor"},
+			want: []string{"hello work", "hello world", "hello worm"},
+		},
+		{
+			name: "prefix kept as typed",
+			args: SuggestArgs{Phrase: "Hello,  goodbye wor", Limit: 2},
+			want: []string{"Hello,  goodbye work", "Hello,  goodbye world"},
+		},
+		{
+			name: "check hits",
+			args: SuggestArgs{Phrase: "hello wor", CheckHits: true, Lang: LangEng},
+			want: []string{"hello world", "hello worm"},
+		},
+		{
+			name: "no match",
+			args: SuggestArgs{Phrase: "hello xyz"},
+		},
+		{
+			name: "no hits",
+			args: SuggestArgs{Phrase: "goodbye worl", CheckHits: true, Lang: LangEng},
+		},
+		{
+			name: "trailing separator",
+			args: SuggestArgs{Phrase: "hello "},
+		},
+		{
+			name: "empty phrase",
+			args: SuggestArgs{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Collection = "messages"
+			tt.args.Bucket = "default"
+			phrases, err := SuggestPhrase(ctx, search, &tt.args)
+			if err != nil {
+				t.Fatalf("SuggestPhrase: %v", err)
+			}
+			if len(tt.want) == 0 {
+				if len(phrases) != 0 {
+					t.Fatalf("SuggestPhrase = %q, want nothing", phrases)
+				}
+				return
+			}
+			if !reflect.DeepEqual(phrases, tt.want) {
+				t.Fatalf("SuggestPhrase = %q, want %q", phrases, tt.want)
+			}
+		})
+	}
+
+	if _, err := SuggestPhrase(ctx, search, nil); err == nil {
+		t.Fatal("SuggestPhrase succeeded without args")
+	}
+}
+
+func TestSuggestPhraseSendsLastWord(t *testing.T) {
+	client, mock := newMockClient(t, `
+> SUGGEST c b "wor" LIMIT(3)
+< PENDING Bz9Bc4Kd
+< EVENT SUGGEST Bz9Bc4Kd world
+`)
+
+	phrases, err := SuggestPhrase(context.Background(), client, &SuggestArgs{Collection: "c", Bucket: "b", Phrase: "new wor", Limit: 3})
+	if err != nil {
+		t.Fatalf("SuggestPhrase: %v", err)
+	}
+	if want := []string{"new world"}; !reflect.DeepEqual(phrases, want) {
+		t.Fatalf("SuggestPhrase = %q, want %q", phrases, want)
+	}
+
+	_ = client.Close()
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatalf("mock: %v", err)
+	}
+}
+
+func TestSuggestWord(t *testing.T) {
+	client := NewClient(&Options{})
+	defer client.Close()
+
+	tests := []struct {
+		in    string
+		word  string
+		ready bool
+	}{
+		{"", "", true},
+		{"hello", "hello", true},
+		{"  hello\t", "hello", true},
+		{"hello world", "hello", false},
+		{" hello  world ", "hello", false},
+		{"hello\nworld", "hello", false},
+	}
+
+	for _, tt := range tests {
+		if got := client.GetSuggestWord(tt.in); got != tt.word {
+			t.Errorf("GetSuggestWord(%q) = %q, want %q", tt.in, got, tt.word)
+		}
+		if got := client.IsSuggestWordReady(tt.in); got != tt.ready {
+			t.Errorf("IsSuggestWordReady(%q) = %v, want %v", tt.in, got, tt.ready)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package sonic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UniversalOptions keeps the settings to setup a UniversalClient.
+type UniversalOptions struct {
+	// Settings shared by the clients of every channel.
+	// ChannelMode and PoolSize are set per channel.
+	Options
+
+	// Maximum number of socket connections of each channel.
+	// Default is Options.PoolSize.
+	SearchPoolSize  int
+	IngestPoolSize  int
+	ControlPoolSize int
+}
+
+func (opt *UniversalOptions) channelOptions(mode string) *Options {
+	o := opt.Options.clone()
+	o.ChannelMode = mode
+
+	switch mode {
+	case ChannelSearch:
+		if opt.SearchPoolSize > 0 {
+			o.PoolSize = opt.SearchPoolSize
+		}
+	case ChannelIngest:
+		if opt.IngestPoolSize > 0 {
+			o.PoolSize = opt.IngestPoolSize
+		}
+	case ChannelControl:
+		if opt.ControlPoolSize > 0 {
+			o.PoolSize = opt.ControlPoolSize
+		}
+	}
+
+	return o
+}
+
+// UniversalClient talks to the search, ingest and control channels of a
+// single Sonic server. Each channel gets its own Client and connection pool,
+// created on first use, and every command is routed to the channel it
+// belongs to. It's safe for concurrent use by multiple goroutines.
+type UniversalClient struct {
+	baseCmdable
+	cmdable
+	ingestCmdable
+	controlCmdable
+
+	opt *UniversalOptions
+
+	mu      sync.Mutex
+	clients map[string]*Client
+	closed  bool
+}
+
+var (
+	_ Cmdable        = (*UniversalClient)(nil)
+	_ IngestCmdable  = (*UniversalClient)(nil)
+	_ ControlCmdable = (*UniversalClient)(nil)
+)
+
+// NewUniversalClient returns a client to the Sonic Server specified by
+// UniversalOptions. No connection is opened until a channel is used.
+func NewUniversalClient(opt *UniversalOptions) *UniversalClient {
+	c := UniversalClient{
+		opt:     opt,
+		clients: make(map[string]*Client),
+	}
+
+	// PING and QUIT are answered by every channel, use the search one.
+	c.baseCmdable = c.channelProcess(ChannelSearch)
+	c.cmdable = c.channelProcess(ChannelSearch)
+	c.ingestCmdable = c.channelProcess(ChannelIngest)
+	c.controlCmdable = c.channelProcess(ChannelControl)
+
+	return &c
+}
+
+// Channel returns the Client of the channel mode, creating it on first use.
+func (c *UniversalClient) Channel(mode string) (*Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+
+	if client, ok := c.clients[mode]; ok {
+		return client, nil
+	}
+
+	switch mode {
+	case ChannelSearch, ChannelIngest, ChannelControl:
+	default:
+		return nil, fmt.Errorf("sonic: unknown channel mode %q", mode)
+	}
+
+	client := NewClient(c.opt.channelOptions(mode))
+	c.clients[mode] = client
+	return client, nil
+}
+
+func (c *UniversalClient) channelProcess(mode string) func(context.Context, Cmder) error {
+	return func(ctx context.Context, cmd Cmder) error {
+		client, err := c.Channel(mode)
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return client.Process(ctx, cmd)
+	}
+}
+
+// Process sends the cmd on the channel its command belongs to.
+func (c *UniversalClient) Process(ctx context.Context, cmd Cmder) error {
+	return c.channelProcess(commandChannel(cmd.Name()))(ctx, cmd)
+}
+
+// Do creates a Cmd from the args and processes the cmd.
+func (c *UniversalClient) Do(ctx context.Context, args ...string) *Cmd {
+	cmd := NewCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
+// MPush pushes every item through the ingest channel, see Client.MPush.
+func (c *UniversalClient) MPush(ctx context.Context, items []IngestItem) *BatchCmd {
+	client, err := c.Channel(ChannelIngest)
+	if err != nil {
+		cmd := newBatchCmd(ctx, items)
+		cmd.SetErr(err)
+		return cmd
+	}
+	return client.MPush(ctx, items)
+}
+
+// MPop pops every item through the ingest channel, see Client.MPop.
+func (c *UniversalClient) MPop(ctx context.Context, items []IngestItem) *BatchCmd {
+	client, err := c.Channel(ChannelIngest)
+	if err != nil {
+		cmd := newBatchCmd(ctx, items)
+		cmd.SetErr(err)
+		return cmd
+	}
+	return client.MPop(ctx, items)
+}
+
+// Pipeline returns a Pipeliner running on the ingest channel.
+func (c *UniversalClient) Pipeline() Pipeliner {
+	pipe := Pipeline{
+		ctx: context.Background(),
+		exec: func(ctx context.Context, cmds []Cmder) error {
+			client, err := c.Channel(ChannelIngest)
+			if err != nil {
+				setCmdsErr(cmds, err)
+				return err
+			}
+			return client.processPipeline(ctx, cmds)
+		},
+	}
+	pipe.init()
+	return &pipe
+}
+
+func (c *UniversalClient) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return c.Pipeline().Pipelined(ctx, fn)
+}
+
+// Mux opens a multiplexed connection on the search channel, see Client.Mux.
+func (c *UniversalClient) Mux(ctx context.Context) (*MuxConn, error) {
+	client, err := c.Channel(ChannelSearch)
+	if err != nil {
+		return nil, err
+	}
+	return client.Mux(ctx)
+}
+
+// PoolStats returns the connection pool stats of every channel in use.
+func (c *UniversalClient) PoolStats() map[string]*PoolStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make(map[string]*PoolStats, len(c.clients))
+	for mode, client := range c.clients {
+		stats[mode] = client.PoolStats()
+	}
+	return stats
+}
+
+// CacheStats returns query cache stats, nil without Options.QueryCache.
+func (c *UniversalClient) CacheStats() *CacheStats {
+	if c.opt.QueryCache == nil {
+		return nil
+	}
+	return c.opt.QueryCache.Stats()
+}
+
+// Close closes the clients of every channel.
+func (c *UniversalClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.closed = true
+
+	var firstErr error
+	for _, client := range c.clients {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
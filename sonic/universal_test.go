@@ -0,0 +1,170 @@
+package sonic
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUniversalClientRouting(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := NewUniversalClient(&UniversalOptions{Options: Options{Addr: srv.Addr}})
+	defer client.Close()
+
+	// The server rejects commands sent on another channel.
+	if err := client.Push(ctx, "messages", "default", "msg:1", "hello world", LangEng).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	ids, err := client.Query(ctx, "messages", "default", "hello", 10, 0, LangEng).Result()
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if want := []string{"msg:1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Query = %q, want %q", ids, want)
+	}
+	if err := client.Trigger(ctx, "consolidate", "").Err(); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	// Do and Process pick the channel from the command name.
+	for _, args := range [][]string{
+		{"COUNT", "messages"},
+		{"suggest", "messages", "default", `"wor"`},
+		{"TRIGGER", "consolidate"},
+	} {
+		if err := client.Do(ctx, args...).Err(); err != nil {
+			t.Errorf("Do(%q): %v", args, err)
+		}
+	}
+
+	stats := client.PoolStats()
+	for _, mode := range []string{ChannelSearch, ChannelIngest, ChannelControl} {
+		if stats[mode] == nil || stats[mode].TotalConns != 1 {
+			t.Errorf("PoolStats()[%q] = %+v, want 1 connection", mode, stats[mode])
+		}
+	}
+}
+
+func TestUniversalClientUsedChannels(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := NewUniversalClient(&UniversalOptions{Options: Options{Addr: srv.Addr}})
+	defer client.Close()
+
+	if err := client.Count(ctx, "messages", "", "").Err(); err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+
+	// Channels are only connected once used.
+	stats := client.PoolStats()
+	if len(stats) != 1 || stats[ChannelIngest] == nil {
+		t.Fatalf("PoolStats() = %v, want the ingest channel only", stats)
+	}
+	if n := srv.ClientsConnected(); n != 1 {
+		t.Fatalf("ClientsConnected() = %d, want 1", n)
+	}
+
+	if _, err := client.Channel("nope"); err == nil {
+		t.Fatal("Channel(nope) succeeded")
+	}
+}
+
+func TestUniversalClientPoolSizes(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  UniversalOptions
+		want map[string]int
+	}{
+		{
+			name: "shared",
+			opt:  UniversalOptions{Options: Options{PoolSize: 4}},
+			want: map[string]int{ChannelSearch: 4, ChannelIngest: 4, ChannelControl: 4},
+		},
+		{
+			name: "per channel",
+			opt:  UniversalOptions{Options: Options{PoolSize: 4}, SearchPoolSize: 8, IngestPoolSize: 2, ControlPoolSize: 1},
+			want: map[string]int{ChannelSearch: 8, ChannelIngest: 2, ChannelControl: 1},
+		},
+		{
+			name: "search only",
+			opt:  UniversalOptions{Options: Options{PoolSize: 4}, SearchPoolSize: 16},
+			want: map[string]int{ChannelSearch: 16, ChannelIngest: 4, ChannelControl: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Channels don't connect until a command is sent.
+			client := NewUniversalClient(&tt.opt)
+			defer client.Close()
+
+			for mode, size := range tt.want {
+				c, err := client.Channel(mode)
+				if err != nil {
+					t.Fatalf("Channel(%q): %v", mode, err)
+				}
+				if got := c.Options(); got.ChannelMode != mode || got.PoolSize != size {
+					t.Errorf("Channel(%q) mode %q pool size %d, want %d", mode, got.ChannelMode, got.PoolSize, size)
+				}
+			}
+
+			// The shared options are left untouched.
+			if tt.opt.ChannelMode != "" {
+				t.Errorf("ChannelMode = %q, want it unset", tt.opt.ChannelMode)
+			}
+		})
+	}
+}
+
+func TestUniversalClientClose(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	client := NewUniversalClient(&UniversalOptions{Options: Options{Addr: srv.Addr}})
+
+	var channels []*Client
+	for _, mode := range []string{ChannelSearch, ChannelIngest, ChannelControl} {
+		c, err := client.Channel(mode)
+		if err != nil {
+			t.Fatalf("Channel(%q): %v", mode, err)
+		}
+		if err := c.Ping(ctx).Err(); err != nil {
+			t.Fatalf("Ping on %s: %v", mode, err)
+		}
+		channels = append(channels, c)
+	}
+	if n := srv.ClientsConnected(); n != 3 {
+		t.Fatalf("ClientsConnected() = %d, want 3", n)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := client.Close(); err != ErrClosed {
+		t.Fatalf("second Close = %v, want %v", err, ErrClosed)
+	}
+
+	for i, c := range channels {
+		if err := c.Ping(ctx).Err(); err != ErrClosed {
+			t.Errorf("Ping on channel %d = %v, want %v", i, err, ErrClosed)
+		}
+	}
+	if err := client.Query(ctx, "messages", "default", "hello", 10, 0, LangEng).Err(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Query after Close = %v, want %v", err, ErrClosed)
+	}
+	if _, err := client.Channel(ChannelSearch); err != ErrClosed {
+		t.Fatalf("Channel after Close = %v, want %v", err, ErrClosed)
+	}
+
+	for deadline := time.Now().Add(time.Second); srv.ClientsConnected() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("ClientsConnected() = %d after Close, want 0", srv.ClientsConnected())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-	"unicode/utf8"
 )
 
 func contains(s []string, str string) bool {
@@ -20,23 +19,18 @@ func contains(s []string, str string) bool {
 	return false
 }
 
-func findDummyIndex(words string) int {
-	return strings.Index(sanitize(words), " ") // -1 not found
-}
-
-func sanitize(text string) string {
-	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"", "'", `\'`)
-	text = r.Replace(text)
-
-	return text
-}
-
-func truncateText(str string, limitter int) string {
-	if utf8.RuneCountInString(str) < limitter {
-		return str
+// parseValues parses key(value) fields such as protocol(1) or uptime(118725).
+// Fields without a value are ignored.
+func parseValues(fields []string) map[string]string {
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		i := strings.IndexByte(field, '(')
+		if i <= 0 || !strings.HasSuffix(field, ")") {
+			continue
+		}
+		values[field[:i]] = field[i+1 : len(field)-1]
 	}
-
-	return string([]rune(str)[:limitter])
+	return values
 }
 
 func Sleep(ctx context.Context, dur time.Duration) error {
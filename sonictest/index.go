@@ -0,0 +1,302 @@
+package sonictest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type object struct {
+	words map[string]struct{}
+	seq   uint64 // last PUSH, newer objects rank first
+}
+
+type bucket struct {
+	objects map[string]*object
+	words   map[string]map[string]struct{} // word -> objects
+}
+
+func newBucket() *bucket {
+	return &bucket{
+		objects: make(map[string]*object),
+		words:   make(map[string]map[string]struct{}),
+	}
+}
+
+// index is an inverted index of collection -> bucket -> word -> objects.
+type index struct {
+	mu          sync.RWMutex
+	seq         uint64
+	collections map[string]map[string]*bucket
+}
+
+func newIndex() *index {
+	return &index{
+		collections: make(map[string]map[string]*bucket),
+	}
+}
+
+// tokenize lowercases text and splits it into words on anything but letters
+// and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func (ix *index) bucket(collection, name string) *bucket {
+	if buckets, ok := ix.collections[collection]; ok {
+		return buckets[name]
+	}
+	return nil
+}
+
+func (ix *index) push(collection, name, obj string, words []string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	buckets, ok := ix.collections[collection]
+	if !ok {
+		buckets = make(map[string]*bucket)
+		ix.collections[collection] = buckets
+	}
+	b, ok := buckets[name]
+	if !ok {
+		b = newBucket()
+		buckets[name] = b
+	}
+	o, ok := b.objects[obj]
+	if !ok {
+		o = &object{words: make(map[string]struct{})}
+		b.objects[obj] = o
+	}
+
+	ix.seq++
+	o.seq = ix.seq
+	for _, word := range words {
+		o.words[word] = struct{}{}
+		objs, ok := b.words[word]
+		if !ok {
+			objs = make(map[string]struct{})
+			b.words[word] = objs
+		}
+		objs[obj] = struct{}{}
+	}
+}
+
+// pop removes the words from the object and returns how many it had.
+func (ix *index) pop(collection, name, obj string, words []string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return 0
+	}
+	o, ok := b.objects[obj]
+	if !ok {
+		return 0
+	}
+
+	var n int
+	for _, word := range words {
+		if _, ok := o.words[word]; !ok {
+			continue
+		}
+		n++
+		b.unlink(obj, word)
+	}
+	if len(o.words) == 0 {
+		delete(b.objects, obj)
+	}
+	return n
+}
+
+func (b *bucket) unlink(obj, word string) {
+	delete(b.objects[obj].words, word)
+	delete(b.words[word], obj)
+	if len(b.words[word]) == 0 {
+		delete(b.words, word)
+	}
+}
+
+// query returns the objects holding every word, most recently pushed first.
+func (ix *index) query(collection, name string, words []string, limit, offset int) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	b := ix.bucket(collection, name)
+	if b == nil || len(words) == 0 {
+		return nil
+	}
+
+	var objs []string
+	for obj := range b.words[words[0]] {
+		match := true
+		for _, word := range words[1:] {
+			if _, ok := b.words[word][obj]; !ok {
+				match = false
+				break
+			}
+		}
+		if match {
+			objs = append(objs, obj)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return b.objects[objs[i]].seq > b.objects[objs[j]].seq
+	})
+
+	return page(objs, limit, offset)
+}
+
+// suggest returns the words starting with prefix in alphabetical order.
+func (ix *index) suggest(collection, name, prefix string, limit int) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var words []string
+	for _, word := range ix.words(collection, name) {
+		if strings.HasPrefix(word, prefix) {
+			words = append(words, word)
+		}
+	}
+	return page(words, limit, 0)
+}
+
+func (ix *index) list(collection, name string, limit, offset int) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return page(ix.words(collection, name), limit, offset)
+}
+
+// words returns the words of a bucket in alphabetical order.
+func (ix *index) words(collection, name string) []string {
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return nil
+	}
+
+	words := make([]string, 0, len(b.words))
+	for word := range b.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func (ix *index) objectWords(collection, name, obj string) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return nil
+	}
+	o, ok := b.objects[obj]
+	if !ok {
+		return nil
+	}
+
+	words := make([]string, 0, len(o.words))
+	for word := range o.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// count returns the number of buckets of a collection, words of a bucket
+// or words of an object, like Sonic, or the number of collections.
+func (ix *index) count(collection, name, obj string) int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if collection == "" {
+		return len(ix.collections)
+	}
+	if name == "" {
+		return len(ix.collections[collection])
+	}
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return 0
+	}
+	if obj == "" {
+		return len(b.words)
+	}
+	if o, ok := b.objects[obj]; ok {
+		return len(o.words)
+	}
+	return 0
+}
+
+// flushCollection removes a collection and returns how many objects it had.
+func (ix *index) flushCollection(collection string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	var n int
+	for _, b := range ix.collections[collection] {
+		n += len(b.objects)
+	}
+	delete(ix.collections, collection)
+	return n
+}
+
+// flushBucket removes a bucket and returns how many objects it had.
+func (ix *index) flushBucket(collection, name string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return 0
+	}
+	delete(ix.collections[collection], name)
+	if len(ix.collections[collection]) == 0 {
+		delete(ix.collections, collection)
+	}
+	return len(b.objects)
+}
+
+// flushObject removes an object and returns how many words it had.
+func (ix *index) flushObject(collection, name, obj string) int {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	b := ix.bucket(collection, name)
+	if b == nil {
+		return 0
+	}
+	o, ok := b.objects[obj]
+	if !ok {
+		return 0
+	}
+
+	n := len(o.words)
+	for word := range o.words {
+		b.unlink(obj, word)
+	}
+	delete(b.objects, obj)
+	return n
+}
+
+func (ix *index) reset() {
+	ix.mu.Lock()
+	ix.collections = make(map[string]map[string]*bucket)
+	ix.mu.Unlock()
+}
+
+func page(values []string, limit, offset int) []string {
+	if offset >= len(values) {
+		return nil
+	}
+	values = values[offset:]
+	if limit < len(values) {
+		values = values[:limit]
+	}
+	return values
+}
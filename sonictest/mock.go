@@ -0,0 +1,179 @@
+package sonictest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockServer replays scripted exchanges to test how the client handles
+// exact, possibly broken, server replies. Steps are played in order across
+// connections, one connection at a time, so clients should use a pool of a
+// single connection. A command line that doesn't match the next step is
+// answered with ERR unexpected_command and closes the connection.
+type MockServer struct {
+	// host:port of the server.
+	Addr string
+
+	listener net.Listener
+	steps    []Step
+
+	mu     sync.Mutex
+	played int
+	err    error
+	conns  []net.Conn
+	closed bool
+
+	done chan struct{}
+}
+
+// NewMockServer starts and returns a new MockServer playing steps.
+// The caller should call Close when finished, to shut it down.
+func NewMockServer(steps []Step) *MockServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("sonictest: failed to listen on a port: %v", err))
+	}
+
+	s := &MockServer{
+		Addr:     ln.Addr().String(),
+		listener: ln,
+		steps:    steps,
+		done:     make(chan struct{}),
+	}
+	go s.serve()
+
+	return s
+}
+
+// NewTranscriptServer starts a MockServer playing the transcript file at
+// path, see ParseTranscript.
+func NewTranscriptServer(path string) (*MockServer, error) {
+	steps, err := ReadTranscript(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMockServer(steps), nil
+}
+
+func (s *MockServer) serve() {
+	defer close(s.done)
+
+	var cn net.Conn
+	var rd *bufio.Reader
+	for i, step := range s.steps {
+		if cn == nil || step.Accept {
+			var err error
+			if cn, err = s.accept(); err != nil {
+				s.fail(fmt.Errorf("sonictest: step %d: %w", i+1, err))
+				return
+			}
+			rd = bufio.NewReader(cn)
+		}
+
+		if step.Expect != "" {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				s.fail(fmt.Errorf("sonictest: step %d: expected %q, got %w", i+1, step.Expect, err))
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line != step.Expect {
+				s.fail(fmt.Errorf("sonictest: step %d: expected %q, got %q", i+1, step.Expect, line))
+				_, _ = cn.Write([]byte("ERR unexpected_command\r\n"))
+				_ = cn.Close()
+				return
+			}
+		}
+
+		if step.Delay > 0 {
+			time.Sleep(step.Delay)
+		}
+
+		var buf strings.Builder
+		for _, line := range step.Reply {
+			buf.WriteString(line + "\r\n")
+		}
+		if _, err := cn.Write([]byte(buf.String())); err != nil {
+			s.fail(fmt.Errorf("sonictest: step %d: %w", i+1, err))
+			return
+		}
+
+		if step.Drop {
+			_ = cn.Close()
+			cn = nil
+		}
+
+		s.mu.Lock()
+		s.played++
+		s.mu.Unlock()
+	}
+
+	// Anything sent after the end of the script is unexpected.
+	if cn != nil {
+		line, err := rd.ReadString('\n')
+		if err == nil {
+			s.fail(fmt.Errorf("sonictest: unexpected %q after the end of the script",
+				strings.TrimRight(line, "\r\n")))
+		}
+	}
+}
+
+func (s *MockServer) accept() (net.Conn, error) {
+	cn, err := s.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = cn.Close()
+		return nil, net.ErrClosed
+	}
+	s.conns = append(s.conns, cn)
+	return cn, nil
+}
+
+func (s *MockServer) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil && !s.closed {
+		s.err = err
+	}
+}
+
+// Err returns the first mismatch with the script, or an error if some
+// steps were not played. Call it after Close.
+func (s *MockServer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.played < len(s.steps) {
+		return fmt.Errorf("sonictest: %d of %d steps not played", len(s.steps)-s.played, len(s.steps))
+	}
+	return nil
+}
+
+// Close shuts down the server and closes every connection.
+func (s *MockServer) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	_ = s.listener.Close()
+	for _, cn := range s.conns {
+		_ = cn.Close()
+	}
+	s.mu.Unlock()
+
+	<-s.done
+}
@@ -0,0 +1,107 @@
+package sonictest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+)
+
+// Recorder writes the traffic of the connections it dials as a transcript
+// that MockServer can replay, see ParseTranscript. Lines of concurrent
+// connections are interleaved, so record with a pool of a single connection.
+// Delays are not recorded.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewRecorder returns a Recorder writing the transcript to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Dialer wraps dial, or a net.Dialer if nil, so that the connections it
+// returns are recorded. It can be used as sonic.Options.Dialer.
+func (r *Recorder) Dialer(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		cn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		r.write("! connect")
+		return &recordedConn{Conn: cn, rec: r}, nil
+	}
+}
+
+// Err returns the first error writing the transcript.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	_, r.err = io.WriteString(r.w, line+"\n")
+}
+
+// record writes the complete lines of buf with prefix and returns the
+// remaining partial line.
+func (r *Recorder) record(prefix string, buf []byte) []byte {
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return buf
+		}
+		r.write(prefix + string(bytes.TrimRight(buf[:i], "\r")))
+		buf = buf[i+1:]
+	}
+}
+
+type recordedConn struct {
+	net.Conn
+	rec *Recorder
+
+	mu      sync.Mutex
+	rd, wr  []byte // partial lines
+	dropped bool
+}
+
+func (c *recordedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	c.mu.Lock()
+	c.rd = c.rec.record("< ", append(c.rd, b[:n]...))
+	if err == io.EOF && !c.dropped {
+		c.dropped = true
+		c.rec.write("! drop")
+	}
+	c.mu.Unlock()
+
+	return n, err
+}
+
+func (c *recordedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+
+	c.mu.Lock()
+	c.wr = c.rec.record("> ", append(c.wr, b[:n]...))
+	c.mu.Unlock()
+
+	return n, err
+}
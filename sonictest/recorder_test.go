@@ -0,0 +1,65 @@
+package sonictest_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/uretgec/go-sonic/sonic"
+	"github.com/uretgec/go-sonic/sonictest"
+)
+
+// session runs the same commands against any server and returns the
+// QUERY results.
+func session(t *testing.T, opt *sonic.Options) []string {
+	t.Helper()
+	ctx := context.Background()
+
+	opt.ChannelMode = sonic.ChannelIngest
+	opt.PoolSize = 1
+	ingest := sonic.NewClient(opt)
+	if err := ingest.Push(ctx, "c", "b", "obj:1", "recorded text", sonic.LangEng).Err(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	_ = ingest.Close()
+
+	searchOpt := *opt
+	searchOpt.ChannelMode = sonic.ChannelSearch
+	search := sonic.NewClient(&searchOpt)
+	defer search.Close()
+	ids, err := search.Query(ctx, "c", "b", "recorded", 10, 0, sonic.LangEng).Result()
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	return ids
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := sonictest.NewServer()
+	defer srv.Close()
+
+	var transcript bytes.Buffer
+	rec := sonictest.NewRecorder(&transcript)
+	recorded := session(t, &sonic.Options{Addr: srv.Addr, Dialer: rec.Dialer(nil)})
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Recorder: %v", err)
+	}
+	if want := []string{"obj:1"}; !reflect.DeepEqual(recorded, want) {
+		t.Fatalf("recorded Query = %q, want %q", recorded, want)
+	}
+
+	steps, err := sonictest.ParseTranscript(&transcript)
+	if err != nil {
+		t.Fatalf("ParseTranscript: %v", err)
+	}
+	mock := sonictest.NewMockServer(steps)
+	replayed := session(t, &sonic.Options{Addr: mock.Addr})
+	mock.Close()
+	if err := mock.Err(); err != nil {
+		t.Fatalf("replay: %v\ntranscript:\n%s", err, transcript.String())
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Fatalf("replayed Query = %q, want %q", replayed, recorded)
+	}
+}
@@ -0,0 +1,496 @@
+// Package sonictest provides an in-memory Sonic server for testing code
+// that uses the sonic package without a real Sonic binary.
+//
+// The server speaks the channel protocol of https://github.com/valeriansaliou/sonic/blob/master/PROTOCOL.md
+// on a local listener: the CONNECTED banner, START with password and buffer
+// size, PING, QUIT, QUERY, SUGGEST and LIST with PENDING/EVENT replies,
+// PUSH, POP, COUNT, FLUSHC, FLUSHB, FLUSHO, INFO and TRIGGER. Text is split
+// into lowercase words on anything but letters and digits and kept in an
+// inverted index; QUERY returns the objects holding every word of its terms,
+// most recently pushed first.
+package sonictest
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uretgec/go-sonic/proto"
+)
+
+// Version is announced in the CONNECTED banner.
+const Version = "sonic-server v1.4.0"
+
+// DefaultBufferSize is the buffer size announced by START when
+// Server.BufferSize is not set, the Sonic default.
+const DefaultBufferSize = 20000
+
+// Server is a Sonic server listening on a local address with an in-memory
+// index.
+type Server struct {
+	// host:port of the server, set by Start.
+	Addr string
+
+	// Password expected by START. Any password, or none, is accepted if
+	// empty.
+	Password string
+
+	// Buffer size announced by START. Longer command lines are rejected
+	// with ERR buffer_overflow. Default is DefaultBufferSize.
+	BufferSize int
+
+	listener net.Listener
+	index    *index
+	started  time.Time
+
+	commandsTotal int64 // atomic
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new Server but doesn't start it, so that
+// its settings can be changed before calling Start.
+func NewUnstartedServer() *Server {
+	return &Server{
+		index: newIndex(),
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// Start starts a server from NewUnstartedServer. It panics if no local
+// address can be listened on.
+func (s *Server) Start() {
+	if s.listener != nil {
+		panic("sonictest: Server already started")
+	}
+	if s.BufferSize == 0 {
+		s.BufferSize = DefaultBufferSize
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("sonictest: failed to listen on a port: %v", err))
+	}
+	s.listener = ln
+	s.Addr = ln.Addr().String()
+	s.started = time.Now()
+
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// Close shuts down the server, closes every client connection and waits
+// for them to finish.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	for cn := range s.conns {
+		_ = cn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// Reset removes every collection from the index.
+func (s *Server) Reset() {
+	s.index.reset()
+}
+
+// Words returns the indexed words of an object in alphabetical order.
+func (s *Server) Words(collection, bucket, object string) []string {
+	return s.index.objectWords(collection, bucket, object)
+}
+
+// ClientsConnected returns the number of open client connections.
+func (s *Server) ClientsConnected() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		cn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = cn.Close()
+			return
+		}
+		s.conns[cn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(cn)
+	}
+}
+
+func (s *Server) serveConn(cn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, cn)
+		s.mu.Unlock()
+		_ = cn.Close()
+	}()
+
+	rd := bufio.NewReader(cn)
+	wr := bufio.NewWriter(cn)
+	sess := &session{}
+
+	if !writeLines(wr, "CONNECTED <"+Version+">") {
+		return
+	}
+
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		size := len(line)
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		atomic.AddInt64(&s.commandsTotal, 1)
+
+		var replies []string
+		var ended bool
+		if size > s.BufferSize {
+			replies = []string{"ERR buffer_overflow"}
+		} else {
+			replies, ended = s.handle(sess, line)
+		}
+
+		if !writeLines(wr, replies...) || ended {
+			return
+		}
+	}
+}
+
+func writeLines(wr *bufio.Writer, lines ...string) bool {
+	for _, line := range lines {
+		if _, err := wr.WriteString(line + "\r\n"); err != nil {
+			return false
+		}
+	}
+	return wr.Flush() == nil
+}
+
+//------------------------------------------------------------------------------
+
+// session is the state of a client connection.
+type session struct {
+	mode string // empty until START
+}
+
+type command struct {
+	mode     string // channel of the command, empty for every channel
+	args     int    // required arguments
+	optional int    // optional arguments following the required ones
+	meta     []string
+	usage    string
+}
+
+var commands = map[string]command{
+	"PING":  {},
+	"QUIT":  {},
+	"START": {args: 1, optional: 1, usage: "START <mode> <password>"},
+
+	"QUERY": {mode: "search", args: 3, meta: []string{"LIMIT", "OFFSET", "LANG"},
+		usage: `QUERY <collection> <bucket> "<terms>" [LIMIT(<count>)]? [OFFSET(<count>)]? [LANG(<locale>)]?`},
+	"SUGGEST": {mode: "search", args: 3, meta: []string{"LIMIT"},
+		usage: `SUGGEST <collection> <bucket> "<word>" [LIMIT(<count>)]?`},
+	"LIST": {mode: "search", args: 2, meta: []string{"LIMIT", "OFFSET"},
+		usage: `LIST <collection> <bucket> [LIMIT(<count>)]? [OFFSET(<count>)]?`},
+
+	"PUSH": {mode: "ingest", args: 4, meta: []string{"LANG"},
+		usage: `PUSH <collection> <bucket> <object> "<text>" [LANG(<locale>)]?`},
+	"POP": {mode: "ingest", args: 4,
+		usage: `POP <collection> <bucket> <object> "<text>"`},
+	"COUNT": {mode: "ingest", args: 1, optional: 2,
+		usage: `COUNT <collection> [<bucket> [<object>]?]?`},
+	"FLUSHC": {mode: "ingest", args: 1, usage: `FLUSHC <collection>`},
+	"FLUSHB": {mode: "ingest", args: 2, usage: `FLUSHB <collection> <bucket>`},
+	"FLUSHO": {mode: "ingest", args: 3, usage: `FLUSHO <collection> <bucket> <object>`},
+
+	"INFO": {mode: "control", usage: "INFO"},
+	"TRIGGER": {mode: "control", optional: 2,
+		usage: `TRIGGER [<action>]? [<data>]?`},
+}
+
+var metaRe = regexp.MustCompile(`^([A-Z]+)\((.*)\)$`)
+
+// request is a parsed command line.
+type request struct {
+	args []string
+	meta map[string]string
+}
+
+func (r *request) arg(i int) string {
+	if i < len(r.args) {
+		return r.args[i]
+	}
+	return ""
+}
+
+func (r *request) metaInt(key string, def int) int {
+	if v, ok := r.meta[key]; ok {
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return def
+}
+
+// handle runs a command line and returns the reply lines, and whether the
+// connection must be closed afterwards.
+func (s *Server) handle(sess *session, line string) (replies []string, ended bool) {
+	args, err := splitArgs(line)
+	if err != nil || len(args) == 0 {
+		return []string{"ERR invalid_format"}, false
+	}
+
+	name := strings.ToUpper(args[0])
+	cmd, ok := commands[name]
+	if !ok {
+		return []string{"ERR unknown_command"}, false
+	}
+	if name != "START" && name != "PING" && name != "QUIT" &&
+		(sess.mode == "" || cmd.mode != sess.mode) {
+		return []string{"ERR unknown_command"}, false
+	}
+
+	req, reply := parseRequest(cmd, args[1:])
+	if reply != "" {
+		return []string{reply}, false
+	}
+
+	switch name {
+	case "PING":
+		return []string{"PONG"}, false
+	case "QUIT":
+		return []string{"ENDED quit"}, true
+	case "START":
+		return s.start(sess, req)
+	case "QUERY":
+		words := tokenize(req.arg(2))
+		objs := s.index.query(req.arg(0), req.arg(1), words, req.metaInt("LIMIT", 10), req.metaInt("OFFSET", 0))
+		return event("QUERY", objs), false
+	case "SUGGEST":
+		var words []string
+		if prefix := tokenize(req.arg(2)); len(prefix) > 0 {
+			words = s.index.suggest(req.arg(0), req.arg(1), prefix[len(prefix)-1], req.metaInt("LIMIT", 5))
+		}
+		return event("SUGGEST", words), false
+	case "LIST":
+		words := s.index.list(req.arg(0), req.arg(1), req.metaInt("LIMIT", 100), req.metaInt("OFFSET", 0))
+		return event("LIST", words), false
+	case "PUSH":
+		words := tokenize(req.arg(3))
+		if len(words) == 0 {
+			return []string{"ERR invalid_format(" + cmd.usage + ")"}, false
+		}
+		s.index.push(req.arg(0), req.arg(1), req.arg(2), words)
+		return []string{"OK"}, false
+	case "POP":
+		n := s.index.pop(req.arg(0), req.arg(1), req.arg(2), tokenize(req.arg(3)))
+		return result(n), false
+	case "COUNT":
+		return result(s.index.count(req.arg(0), req.arg(1), req.arg(2))), false
+	case "FLUSHC":
+		return result(s.index.flushCollection(req.arg(0))), false
+	case "FLUSHB":
+		return result(s.index.flushBucket(req.arg(0), req.arg(1))), false
+	case "FLUSHO":
+		return result(s.index.flushObject(req.arg(0), req.arg(1), req.arg(2))), false
+	case "INFO":
+		return []string{s.info()}, false
+	case "TRIGGER":
+		return trigger(req, cmd), false
+	}
+	return []string{"ERR unknown_command"}, false
+}
+
+func (s *Server) start(sess *session, req *request) ([]string, bool) {
+	if sess.mode != "" {
+		return []string{"ERR unknown_command"}, false
+	}
+
+	mode := strings.ToLower(req.arg(0))
+	switch mode {
+	case "search", "ingest", "control":
+	default:
+		return []string{"ENDED invalid_mode"}, true
+	}
+	if s.Password != "" && req.arg(1) != s.Password {
+		return []string{"ENDED authentication_failed"}, true
+	}
+
+	sess.mode = mode
+	return []string{fmt.Sprintf("STARTED %s protocol(1) buffer(%d)", mode, s.BufferSize)}, false
+}
+
+func (s *Server) info() string {
+	return fmt.Sprintf(
+		"RESULT uptime(%d) clients_connected(%d) commands_total(%d) command_latency_best(0) command_latency_worst(0) kv_open_count(%d) fst_open_count(0) fst_consolidate_count(0)",
+		int64(time.Since(s.started)/time.Second), s.ClientsConnected(), atomic.LoadInt64(&s.commandsTotal), s.index.count("", "", ""),
+	)
+}
+
+func trigger(req *request, cmd command) []string {
+	switch req.arg(0) {
+	case "consolidate":
+		if len(req.args) == 1 {
+			return []string{"OK"}
+		}
+	case "backup", "restore":
+		if len(req.args) == 2 {
+			return []string{"OK"}
+		}
+	}
+	return []string{"ERR invalid_format(" + cmd.usage + ")"}
+}
+
+// parseRequest splits the arguments of a command into positional arguments
+// and meta arguments, e.g. LIMIT(10). It returns the ERR reply line when
+// they don't match the command.
+func parseRequest(cmd command, args []string) (*request, string) {
+	req := &request{meta: make(map[string]string)}
+	invalid := "ERR invalid_format(" + cmd.usage + ")"
+
+	for i, arg := range args {
+		if i >= cmd.args {
+			if m := metaRe.FindStringSubmatch(arg); m != nil {
+				if !contains(cmd.meta, m[1]) {
+					return nil, "ERR invalid_meta_key(" + m[1] + "[" + m[2] + "])"
+				}
+				if !validMeta(m[1], m[2]) {
+					return nil, "ERR invalid_meta_value(" + m[1] + "[" + m[2] + "])"
+				}
+				req.meta[m[1]] = m[2]
+				continue
+			}
+		}
+		if len(req.meta) > 0 || len(req.args) >= cmd.args+cmd.optional {
+			return nil, invalid
+		}
+		req.args = append(req.args, arg)
+	}
+
+	if len(req.args) < cmd.args {
+		return nil, invalid
+	}
+	return req, ""
+}
+
+var langRe = regexp.MustCompile(`^([a-z]{3}|none)$`)
+
+func validMeta(key, value string) bool {
+	switch key {
+	case "LIMIT", "OFFSET":
+		n, err := strconv.Atoi(value)
+		return err == nil && n >= 0
+	case "LANG":
+		return langRe.MatchString(value)
+	}
+	return false
+}
+
+// splitArgs splits a command line on spaces. Quoted text is kept as one
+// argument and unquoted.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+
+		start := i
+		if line[i] == '"' {
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("sonictest: unterminated text in %q", line)
+			}
+			i++
+			args = append(args, proto.UnquoteText(line[start:i]))
+			continue
+		}
+
+		for i < len(line) && line[i] != ' ' {
+			i++
+		}
+		args = append(args, line[start:i])
+	}
+	return args, nil
+}
+
+// event returns the PENDING and EVENT lines of a search command.
+func event(kind string, results []string) []string {
+	marker := newMarker()
+	ev := "EVENT " + kind + " " + marker
+	if len(results) > 0 {
+		ev += " " + strings.Join(results, " ")
+	}
+	return []string{"PENDING " + marker, ev}
+}
+
+func result(n int) []string {
+	return []string{"RESULT " + strconv.Itoa(n)}
+}
+
+const markerChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func newMarker() string {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = markerChars[rand.Intn(len(markerChars))]
+	}
+	return string(b)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package sonictest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Step is one exchange played by a MockServer.
+type Step struct {
+	// Accept waits for a new client connection before the step.
+	// The first step always does.
+	Accept bool
+
+	// Command line expected from the client, without CRLF. The step is
+	// played without reading anything if empty.
+	Expect string
+
+	// Delay before sending Reply.
+	Delay time.Duration
+
+	// Reply lines sent to the client, without CRLF.
+	Reply []string
+
+	// Drop closes the connection after Reply is sent.
+	Drop bool
+}
+
+// ParseTranscript reads the steps of a transcript, as written by Recorder.
+// Every line starts with a prefix:
+//
+//	> QUERY c b "terms"   command line sent by the client
+//	< PENDING Bz9Bc4Kd    reply line sent by the server
+//	! connect             the client opens a new connection
+//	! sleep 100ms         pause before the next reply lines
+//	! drop                the server closes the connection
+//	# comment
+//
+// Blank lines are ignored.
+func ParseTranscript(r io.Reader) ([]Step, error) {
+	var steps []Step
+	var cur *Step
+
+	next := func() *Step {
+		steps = append(steps, Step{})
+		return &steps[len(steps)-1]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		prefix, text := line[0], ""
+		if len(line) > 2 && line[1] == ' ' {
+			text = line[2:]
+		} else if len(line) > 1 {
+			return nil, fmt.Errorf("sonictest: transcript line %d: missing space after %q", n, prefix)
+		}
+
+		switch prefix {
+		case '>':
+			cur = next()
+			cur.Expect = text
+		case '<':
+			if cur == nil || cur.Drop {
+				cur = next()
+			}
+			cur.Reply = append(cur.Reply, text)
+		case '!':
+			fields := strings.Fields(text)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("sonictest: transcript line %d: missing directive", n)
+			}
+			switch fields[0] {
+			case "connect":
+				cur = next()
+				cur.Accept = true
+			case "sleep":
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("sonictest: transcript line %d: sleep needs a duration", n)
+				}
+				d, err := time.ParseDuration(fields[1])
+				if err != nil {
+					return nil, fmt.Errorf("sonictest: transcript line %d: %w", n, err)
+				}
+				if cur == nil || cur.Drop || len(cur.Reply) > 0 {
+					cur = next()
+				}
+				cur.Delay += d
+			case "drop":
+				if cur == nil || cur.Drop {
+					cur = next()
+				}
+				cur.Drop = true
+			default:
+				return nil, fmt.Errorf("sonictest: transcript line %d: unknown directive %q", n, fields[0])
+			}
+		default:
+			return nil, fmt.Errorf("sonictest: transcript line %d: unknown prefix %q", n, prefix)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// ReadTranscript reads the steps of the transcript file at path.
+func ReadTranscript(path string) ([]Step, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseTranscript(f)
+}